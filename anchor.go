@@ -0,0 +1,135 @@
+package bento
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Anchor attaches a box to a side of the box with the Target id, e.g. anchor="#save bottom-left"
+// places the box below the box with id="save", lined up with its left edge
+// Side is top, bottom, left or right, and Align is the edge along that side to line up with, or center
+type Anchor struct {
+	Target      string
+	Side, Align string
+}
+
+// parse anchor spec e.g. "#target bottom-left", "#target right"
+func parseAnchor(spec string) (*Anchor, error) {
+	a := strings.Fields(spec)
+	if len(a) != 2 || !strings.HasPrefix(a[0], "#") || len(a[0]) == 1 {
+		return nil, fmt.Errorf("invalid anchor spec %q, expected #id side-align", spec)
+	}
+	anchor := &Anchor{Target: a[0][1:], Align: "center"}
+	parts := strings.SplitN(a[1], "-", 2)
+	anchor.Side = parts[0]
+	if len(parts) == 2 {
+		anchor.Align = parts[1]
+	}
+	var aligns []string
+	switch anchor.Side {
+	case "top", "bottom":
+		aligns = []string{"left", "right", "center"}
+	case "left", "right":
+		aligns = []string{"top", "bottom", "center"}
+	default:
+		return nil, fmt.Errorf("invalid anchor side %s, expected top, bottom, left or right", anchor.Side)
+	}
+	for _, align := range aligns {
+		if anchor.Align == align {
+			return anchor, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid anchor alignment %s for side %s", anchor.Align, anchor.Side)
+}
+
+// find the box with the given id
+func (n *Box) byID(id string) *Box {
+	if n.Attrs["id"] == id {
+		return n
+	}
+	for _, c := range n.Children {
+		if b := c.byID(id); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+// check that the target of every anchored box exists
+func (n *Box) checkAnchors() error {
+	root := n.root()
+	return n.visit(0, func(_ int, b *Box) error {
+		if a := b.Style.Anchor; a != nil && root.byID(a.Target) == nil {
+			return fmt.Errorf("error anchoring %s: no box with id %s", b.Tag, a.Target)
+		}
+		return nil
+	})
+}
+
+// move anchored boxes next to their targets, once every box has been placed
+func (n *Box) anchor() {
+	if a := n.Style.Anchor; a != nil && n.Style.Display {
+		if target := n.root().byID(a.Target); target != nil {
+			x, y := n.anchorPosition(a, target)
+			n.translate(x-n.X, y-n.Y)
+		}
+	}
+	for _, c := range n.Children {
+		c.anchor()
+	}
+}
+
+// the position of the box on the side of the target, flipped to the opposite side if it would leave the screen,
+// and moved along the side to stay on screen
+func (n *Box) anchorPosition(a *Anchor, target *Box) (int, int) {
+	t := target.innerRect()
+	w, h := n.OuterWidth, n.OuterHeight
+	vw, vh := n.viewport()
+	side := a.Side
+	switch {
+	case vh > 0 && side == "bottom" && t.Max.Y+h > vh && t.Min.Y-h >= 0:
+		side = "top"
+	case vh > 0 && side == "top" && t.Min.Y-h < 0 && t.Max.Y+h <= vh:
+		side = "bottom"
+	case vw > 0 && side == "right" && t.Max.X+w > vw && t.Min.X-w >= 0:
+		side = "left"
+	case vw > 0 && side == "left" && t.Min.X-w < 0 && t.Max.X+w <= vw:
+		side = "right"
+	}
+	var x, y int
+	switch side {
+	case "top":
+		y = t.Min.Y - h
+	case "bottom":
+		y = t.Max.Y
+	case "left":
+		x = t.Min.X - w
+	case "right":
+		x = t.Max.X
+	}
+	switch a.Align {
+	case "left":
+		x = t.Min.X
+	case "right":
+		x = t.Max.X - w
+	case "top":
+		y = t.Min.Y
+	case "bottom":
+		y = t.Max.Y - h
+	case "center":
+		if side == "top" || side == "bottom" {
+			x = t.Min.X + t.Dx()/2 - w/2
+		} else {
+			y = t.Min.Y + t.Dy()/2 - h/2
+		}
+	}
+	x += n.Style.OffsetX
+	y += n.Style.OffsetY
+	if vw > 0 && (side == "top" || side == "bottom") {
+		x = max(0, min(x, vw-w))
+	}
+	if vh > 0 && (side == "left" || side == "right") {
+		y = max(0, min(y, vh-h))
+	}
+	return x, y
+}
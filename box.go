@@ -26,21 +26,38 @@ const (
 
 var (
 	debug bool
+	// the default UI scale, replaced in tests that run without a display
+	deviceScaleFactor = ebiten.DeviceScaleFactor
 )
 
 type Box struct {
-	Tag        string
-	Parent     *Box
-	Children   []*Box
-	Content    string
-	Component  Component
-	Attrs      map[string]string
-	Style      Style
-	state      State
-	scrollable Scrollable
-	editable   *Editable
-	dirty      bool
-	target     *ebiten.Image
+	Tag          string
+	Parent       *Box
+	Children     []*Box
+	Content      string
+	Component    Component
+	Attrs        map[string]string
+	Style        Style
+	state        State
+	scrollable   Scrollable
+	editable     *Editable
+	handlers     map[string]func(*Event) bool
+	bound        map[string]func(*Event) bool
+	grid         *gridLayout
+	breaks       []int
+	aspectMin    image.Point
+	list         *virtualList
+	items        reflect.Value
+	base         Style
+	responsive   bool
+	breakpoint   string
+	registry     map[string]func(*Event) bool
+	dirty        bool
+	target       *ebiten.Image
+	scale        float64
+	deviceScaled bool
+	screen       screenTransform
+	cache        layoutCache
 	layout
 }
 
@@ -52,6 +69,9 @@ func Build(c Component) (*Box, error) {
 	if err := root.build(nil); err != nil {
 		return nil, err
 	}
+	if err := root.checkAnchors(); err != nil {
+		return nil, err
+	}
 	return root, nil
 }
 
@@ -89,7 +109,7 @@ func (n *Box) update(ctx *context) error {
 	n.state = idle
 	if n.Attrs["disabled"] == "true" {
 		n.state = disabled
-	} else if x, y := ebiten.CursorPosition(); !ctx.consumed && inside(n.innerRect(), x, y) {
+	} else if x, y, ok := n.cursor(); ok && !ctx.consumed && inside(n.innerRect(), x, y) && !n.clipped(x, y) {
 		if sx, sy := ebiten.Wheel(); sx != 0 || sy != 0 {
 			ctx.consumed = n.fireEvent(Scroll, "", nil, nil)
 		} else {
@@ -109,9 +129,12 @@ func (n *Box) update(ctx *context) error {
 	if err := n.editable.update(n, ctx); err != nil {
 		return err
 	}
-	if err := n.scrollable.update(n); err != nil {
+	if err := n.scrollable.update(n, ctx); err != nil {
 		return err
 	}
+	if n.list != nil && n.list.err != nil {
+		return n.list.err
+	}
 	n.fireEvent(Update, "", nil, nil)
 	if n.Parent == nil {
 		ctx.keys = ctx.keys[:0]
@@ -124,11 +147,19 @@ func (n *Box) update(ctx *context) error {
 
 func (n *Box) Rebuild() error {
 	new := &Box{
-		Component: n.Component,
+		Component:    n.Component,
+		target:       n.target,
+		scale:        n.scale,
+		deviceScaled: n.deviceScaled,
+		screen:       n.screen,
 	}
 	if err := new.build(n); err != nil {
 		return err
 	}
+	if err := new.checkAnchors(); err != nil {
+		return err
+	}
+	new.registry = n.registry
 	*n = *new
 	for _, child := range n.Children {
 		child.Parent = n
@@ -138,6 +169,26 @@ func (n *Box) Rebuild() error {
 	return nil
 }
 
+// Scale is the factor sizes in px, fonts and nine slices are multiplied by, which defaults to 1, or to the device
+// scale factor once Layout is used to draw at the resolution of the device without a virtual resolution
+func (n *Box) Scale() float64 {
+	root := n.root()
+	switch {
+	case root.scale != 0:
+		return root.scale
+	case root.deviceScaled && root.screen.virtual == (image.Point{}):
+		return deviceScaleFactor()
+	}
+	return 1
+}
+
+// SetScale changes the UI scale, e.g. for accessibility settings, and rebuilds the UI on the next update
+func (n *Box) SetScale(scale float64) {
+	root := n.root()
+	root.scale = scale
+	root.dirty = true
+}
+
 func (n *Box) ToggleDebug() {
 	debug = !debug
 }
@@ -193,6 +244,23 @@ func max(a, b int) int {
 	return b
 }
 
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// whether the point is hidden by an ancestor that clips its overflowing children
+func (n *Box) clipped(x, y int) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Style.clips() && !inside(p.innerRect(), x, y) {
+			return true
+		}
+	}
+	return false
+}
+
 func inside(r image.Rectangle, x, y int) bool {
 	return x >= r.Min.X && x <= r.Max.X && y >= r.Min.Y && y <= r.Max.Y
 }
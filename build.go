@@ -5,6 +5,7 @@ package bento
 
 import (
 	"bytes"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"reflect"
@@ -14,6 +15,8 @@ import (
 	"text/template"
 	"unicode"
 	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
 )
 
 func (n *Box) isSubcomponent() bool {
@@ -33,7 +36,9 @@ func (n *Box) buildSubcomponent(prev *Box) error {
 	}
 	if style, ok := subComponent.Interface().(*Style); ok {
 		n.Style = *style
+		n.Style.node = n
 		n.Tag = style.Extends
+		n.reuseLayout(prev)
 		return nil
 	} else if sub, ok := subComponent.Interface().(Component); ok {
 		subNode := &Box{
@@ -61,10 +66,24 @@ func (n *Box) build(prev *Box) error {
 	if n.isSubcomponent() {
 		return n.buildSubcomponent(prev)
 	}
-	n.Style.adopt(n)
-	if err := n.Style.parseAttributes(); err != nil {
+	responsive, err := n.isResponsive()
+	if err != nil {
 		return err
 	}
+	if responsive {
+		// keep the unparsed style to parse again when the screen crosses a breakpoint
+		n.responsive = true
+		n.base = n.Style
+		n.breakpoint = n.currentBreakpoint()
+		if err := n.checkBreakpoints(); err != nil {
+			return err
+		}
+	} else {
+		n.Style.adopt(n)
+		if err := n.Style.parseAttributes(); err != nil {
+			return err
+		}
+	}
 	if prev != nil && n.Tag == prev.Tag {
 		n.state = prev.state
 		n.editable = prev.editable
@@ -72,9 +91,18 @@ func (n *Box) build(prev *Box) error {
 	} else if n.Tag == "input" || n.Tag == "textarea" {
 		n.editable = &Editable{}
 	}
-	if !n.Style.Display || n.Style.Hidden {
+	n.reuseLayout(prev)
+	n.bindHandlers()
+	// responsive boxes build their children in case they are shown at another breakpoint
+	if (!n.Style.Display || n.Style.Hidden) && !n.responsive {
 		return nil
 	}
+	if n.Tag == "list" {
+		return n.buildList()
+	}
+	if err := n.applyDirectives(); err != nil {
+		return err
+	}
 	i := 0
 	for _, child := range n.Children {
 		if child.Component == nil {
@@ -91,10 +119,19 @@ func (n *Box) build(prev *Box) error {
 			i++
 		}
 	}
+	n.compareChildren(prev)
 	return nil
 }
 
 func (n *Box) expandComponent() error {
+	if e, ok := n.Component.(*Element); ok {
+		if e.component != nil {
+			n.Component = e.component
+			return n.expandComponent()
+		}
+		e.expand(n)
+		return nil
+	}
 	tmpl, err := template.New("").Parse(n.Component.UI())
 	if err != nil {
 		return err
@@ -103,6 +140,18 @@ func (n *Box) expandComponent() error {
 	if err := tmpl.Execute(buf, n.Component); err != nil {
 		return err
 	}
+	name := reflect.ValueOf(n.Component).Elem().Type().Name()
+	if markup := bytes.TrimSpace(buf.Bytes()); len(markup) > 0 && markup[0] == '{' {
+		if err := json.Unmarshal(markup, n); err != nil {
+			return fmt.Errorf("error building %s: %s", name, err)
+		}
+		return nil
+	} else if len(markup) > 0 && markup[0] != '<' {
+		if err := yaml.Unmarshal(markup, n); err != nil {
+			return fmt.Errorf("error building %s: %s", name, err)
+		}
+		return nil
+	}
 	if err := xml.Unmarshal(buf.Bytes(), n); err != nil {
 		// TODO: Good error reporting if parsing the XML fails
 		re := regexp.MustCompile(`error on line (\d+)`)
@@ -117,9 +166,9 @@ func (n *Box) expandComponent() error {
 					ctx.WriteByte('\n')
 				}
 			}
-			return fmt.Errorf("error building %s: %s\n%s", reflect.ValueOf(n.Component).Elem().Type().Name(), err, ctx)
+			return fmt.Errorf("error building %s: %s\n%s", name, err, ctx)
 		}
-		return fmt.Errorf("error building %s: %s", reflect.ValueOf(n.Component).Elem().Type().Name(), err)
+		return fmt.Errorf("error building %s: %s", name, err)
 	}
 	return nil
 }
@@ -152,6 +201,14 @@ func (a *Box) diff(b *Box) error {
 	if a.Content != b.Content {
 		return fmt.Errorf("content mismatch, %q != %q", a.Content, b.Content)
 	}
+	if len(a.Attrs) != len(b.Attrs) {
+		return fmt.Errorf("different number of attributes on %s, %d != %d", a.Tag, len(a.Attrs), len(b.Attrs))
+	}
+	for k, v := range a.Attrs {
+		if b.Attrs[k] != v {
+			return fmt.Errorf("attribute %s mismatch on %s, %q != %q", k, a.Tag, v, b.Attrs[k])
+		}
+	}
 	if len(a.Children) != len(b.Children) {
 		return fmt.Errorf("different number of children, %d != %d", len(a.Children), len(b.Children))
 	}
@@ -256,3 +256,77 @@ func TestRebuildSubcomponent(t *testing.T) {
 		t.Fatalf("got %d nodes, want %d", nodes, want)
 	}
 }
+
+type DirectiveItem struct {
+	Name  string
+	Count int
+}
+
+func (i DirectiveItem) Plural() bool {
+	return i.Count != 1
+}
+
+type DirectiveComponent struct {
+	Count int
+	Items []DirectiveItem
+}
+
+func (c *DirectiveComponent) UI() string {
+	return `<col>
+		<text if="{{ eq .Count 1 }}">One</text>
+		<text show="{{ gt .Count 1 }}">Many</text>
+		<text hide="{{ gt .Count 1 }}">Few</text>
+		<row each="Items" as="item">
+			<text>${item.Name}</text>
+			<text if="${item.Plural}">${item.Count} items</text>
+		</row>
+	</col>`
+}
+
+func TestBuildDirectives(t *testing.T) {
+	c := &DirectiveComponent{
+		Count: 1,
+		Items: []DirectiveItem{{"a", 1}, {"b", 2}},
+	}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `col <DirectiveComponent>
+	text "One"
+	text "Many"
+	text "Few"
+	row
+		text "a"
+	row
+		text "b"
+		text "2 items"
+`
+	got := box.String()
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s\n", got, want)
+	}
+	if box.Children[1].Style.Display {
+		t.Fatal("show=false should not display the box")
+	}
+	if !box.Children[2].Style.Display {
+		t.Fatal("hide=false should display the box")
+	}
+
+	c.Count = 2
+	c.Items = nil
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	want = `col <DirectiveComponent>
+	text "Many"
+	text "Few"
+`
+	got = box.String()
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s\n", got, want)
+	}
+	if !box.Children[0].Style.Display || box.Children[1].Style.Display {
+		t.Fatal("show and hide were not re-evaluated on rebuild")
+	}
+}
@@ -0,0 +1,294 @@
+package bento
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// An Element describes a box built in Go rather than markup, e.g.
+// bento.Col(bento.Margin(24), bento.Text("Hello").Font("NotoSans", 24), bento.Button("OK").OnClick(fn))
+// Elements are Components, so they can be passed to Build or returned from a subcomponent field or method.
+// An element tree is static: an Element passed to Build is the same on every Rebuild, so state that changes should
+// be read by a subcomponent method that returns a new Element each time it is called.
+type Element struct {
+	tag       string
+	attrs     map[string]string
+	content   string
+	handlers  map[string]func(*Event) bool
+	children  []*Element
+	component Component
+	items     reflect.Value
+}
+
+// A Node is either a child Element or an Option applied to the enclosing Element
+type Node interface {
+	applyTo(e *Element)
+}
+
+// An Option sets attributes on the enclosing Element
+type Option func(e *Element)
+
+func (o Option) applyTo(e *Element) {
+	o(e)
+}
+
+func (e *Element) applyTo(parent *Element) {
+	parent.children = append(parent.children, e)
+}
+
+func newElement(tag, content string, nodes ...Node) *Element {
+	e := &Element{
+		tag:     tag,
+		attrs:   make(map[string]string),
+		content: content,
+	}
+	for _, n := range nodes {
+		n.applyTo(e)
+	}
+	return e
+}
+
+func Row(nodes ...Node) *Element {
+	return newElement("row", "", nodes...)
+}
+
+func Col(nodes ...Node) *Element {
+	return newElement("col", "", nodes...)
+}
+
+// Grid places its children in the cells of its columns and rows, set with the Columns and Rows options
+func Grid(nodes ...Node) *Element {
+	return newElement("grid", "", nodes...)
+}
+
+// Stack overlaps its children, each aligned within the stack with JustifySelf
+func Stack(nodes ...Node) *Element {
+	return newElement("stack", "", nodes...)
+}
+
+// List builds the row for each of the items, a slice or array, from the template, only for the rows visible in its
+// scroll viewport, e.g. bento.List(lines, bento.Text("${item}"), bento.Attr("maxHeight", "300px"))
+func List(items interface{}, row *Element, opts ...Option) *Element {
+	e := newElement("list", "", append(options(opts), row)...)
+	e.items = reflect.ValueOf(items)
+	return e
+}
+
+func Canvas(nodes ...Node) *Element {
+	return newElement("canvas", "", nodes...)
+}
+
+func Text(content string, opts ...Option) *Element {
+	return newElement("text", content, options(opts)...)
+}
+
+func Paragraph(content string, opts ...Option) *Element {
+	return newElement("p", content, options(opts)...)
+}
+
+func Button(content string, opts ...Option) *Element {
+	return newElement("button", content, options(opts)...)
+}
+
+func Img(src string, opts ...Option) *Element {
+	return newElement("img", "", append(options(opts), Attr("src", src))...)
+}
+
+func Input(value string, opts ...Option) *Element {
+	return newElement("input", "", append(options(opts), Attr("value", value))...)
+}
+
+func Textarea(value string, opts ...Option) *Element {
+	return newElement("textarea", "", append(options(opts), Attr("value", value))...)
+}
+
+// Sub embeds a component, e.g. one defined with XML markup, as a child element
+func Sub(c Component) *Element {
+	return &Element{component: c}
+}
+
+func options(opts []Option) []Node {
+	nodes := make([]Node, len(opts))
+	for i, o := range opts {
+		nodes[i] = o
+	}
+	return nodes
+}
+
+func Attr(name, value string) Option {
+	return func(e *Element) {
+		e.attrs[name] = value
+	}
+}
+
+func Font(name string, size int) Option {
+	return Attr("font", fmt.Sprintf("%s %d", name, size))
+}
+
+func Color(spec string) Option {
+	return Attr("color", spec)
+}
+
+// Margin in pixels, with the same 1, 2 or 4 values as the margin attribute
+func Margin(px ...int) Option {
+	return Attr("margin", pixels(px))
+}
+
+// Padding in pixels, with the same 1, 2 or 4 values as the padding attribute
+func Padding(px ...int) Option {
+	return Attr("padding", pixels(px))
+}
+
+func Grow(factors ...int) Option {
+	a := make([]string, len(factors))
+	for i, f := range factors {
+		a[i] = fmt.Sprint(f)
+	}
+	return Attr("grow", strings.Join(a, " "))
+}
+
+func Justify(h, v Justification) Option {
+	return Attr("justify", fmt.Sprintf("%s %s", h, v))
+}
+
+func JustifySelf(h, v Justification) Option {
+	return Attr("justifySelf", fmt.Sprintf("%s %s", h, v))
+}
+
+// Columns of a grid, with the same tracks as the columns attribute, e.g. Columns("100px", "1fr", "2fr")
+func Columns(tracks ...string) Option {
+	return Attr("columns", strings.Join(tracks, " "))
+}
+
+// Rows of a grid, with the same tracks as the rows attribute
+func Rows(tracks ...string) Option {
+	return Attr("rows", strings.Join(tracks, " "))
+}
+
+// Span the number of columns and rows of a grid
+func Span(cols, rows int) Option {
+	return func(e *Element) {
+		e.attrs["colSpan"] = fmt.Sprint(cols)
+		e.attrs["rowSpan"] = fmt.Sprint(rows)
+	}
+}
+
+func pixels(px []int) string {
+	a := make([]string, len(px))
+	for i, p := range px {
+		a[i] = fmt.Sprintf("%dpx", p)
+	}
+	return strings.Join(a, " ")
+}
+
+func (e *Element) With(opts ...Option) *Element {
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+func (e *Element) Attr(name, value string) *Element {
+	return e.With(Attr(name, value))
+}
+
+func (e *Element) Font(name string, size int) *Element {
+	return e.With(Font(name, size))
+}
+
+func (e *Element) Color(spec string) *Element {
+	return e.With(Color(spec))
+}
+
+func (e *Element) Margin(px ...int) *Element {
+	return e.With(Margin(px...))
+}
+
+func (e *Element) Padding(px ...int) *Element {
+	return e.With(Padding(px...))
+}
+
+func (e *Element) Grow(factors ...int) *Element {
+	return e.With(Grow(factors...))
+}
+
+func (e *Element) Justify(h, v Justification) *Element {
+	return e.With(Justify(h, v))
+}
+
+func (e *Element) JustifySelf(h, v Justification) *Element {
+	return e.With(JustifySelf(h, v))
+}
+
+func (e *Element) Span(cols, rows int) *Element {
+	return e.With(Span(cols, rows))
+}
+
+// On registers a closure to handle events of the given type, in place of a method name
+func (e *Element) On(t EventType, fn func(*Event) bool) *Element {
+	if e.handlers == nil {
+		e.handlers = make(map[string]func(*Event) bool)
+	}
+	e.handlers["on"+string(t)] = fn
+	return e
+}
+
+func (e *Element) OnClick(fn func(*Event) bool) *Element {
+	return e.On(Click, fn)
+}
+
+func (e *Element) OnHover(fn func(*Event) bool) *Element {
+	return e.On(Hover, fn)
+}
+
+func (e *Element) OnChange(fn func(*Event) bool) *Element {
+	return e.On(Change, fn)
+}
+
+// Markup returns the element tree as XML markup, without its event handlers
+func (e *Element) Markup() (string, error) {
+	b := &Box{Component: e}
+	if err := b.build(nil); err != nil {
+		return "", fmt.Errorf("error building element: %s", err)
+	}
+	data, err := xml.Marshal(b)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling element: %s", err)
+	}
+	return string(data), nil
+}
+
+// UI returns the markup of the element tree, logging the error and returning no markup if it can't be built
+func (e *Element) UI() string {
+	markup, err := e.Markup()
+	if err != nil {
+		log.Println(err)
+		return ""
+	}
+	return markup
+}
+
+// copy the element tree into the box, leaving embedded components to be expanded by build
+func (e *Element) expand(n *Box) {
+	n.Tag = e.tag
+	n.Content = e.content
+	n.Attrs = make(map[string]string)
+	for k, v := range e.attrs {
+		n.Attrs[k] = v
+	}
+	n.handlers = e.handlers
+	n.items = e.items
+	n.Children = nil
+	for _, c := range e.children {
+		child := &Box{Parent: n}
+		if c.component != nil {
+			child.Component = c.component
+		} else {
+			c.expand(child)
+		}
+		n.Children = append(n.Children, child)
+	}
+}
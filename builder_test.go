@@ -0,0 +1,163 @@
+package bento
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type BuilderComponent struct {
+	Clicks int
+}
+
+func (c *BuilderComponent) Menu() Component {
+	return Col(
+		Margin(24),
+		Text("Hello").Font("NotoSans", 24),
+		Sub(&SubComponent{Count: c.Clicks}),
+		Button("OK").Padding(12).OnClick(func(_ *Event) bool {
+			c.Clicks++
+			return true
+		}),
+	)
+}
+
+func (c *BuilderComponent) UI() string {
+	return `<row>
+		<Menu />
+	</row>`
+}
+
+func TestBuilder(t *testing.T) {
+	c := &BuilderComponent{}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Box{
+		Tag: "row",
+		Children: []*Box{
+			{
+				Tag:   "col",
+				Attrs: map[string]string{"margin": "24px"},
+				Children: []*Box{
+					{Tag: "text", Content: "Hello", Attrs: map[string]string{"font": "NotoSans 24"}},
+					{Tag: "col", Children: []*Box{{Tag: "text", Content: "0"}}},
+					{Tag: "button", Content: "OK", Attrs: map[string]string{"padding": "12px"}},
+				},
+			},
+		},
+	}
+	if err := box.diff(want); err != nil {
+		t.Fatal(err)
+	}
+	menu := box.Children[0]
+	if menu.Style.Margin.Left != 24 || menu.Children[0].Style.FontSize != 24 {
+		t.Fatal("builder attributes were not parsed into the style")
+	}
+	for _, child := range menu.Children {
+		if child.Parent != menu {
+			t.Fatal("child has incorrect parent")
+		}
+	}
+
+	button := menu.Children[2]
+	if !button.fireEvent(Click, "", nil, nil) {
+		t.Fatal("click handler was not called")
+	}
+	if c.Clicks != 1 || !box.dirty {
+		t.Fatalf("got %d clicks, dirty=%t, want 1 click and dirty=true", c.Clicks, box.dirty)
+	}
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if got := box.Children[0].Children[1].Children[0].Content; got != "1" {
+		t.Fatalf("got %q, want subcomponent rebuilt with 1 click", got)
+	}
+}
+
+func TestBuilderGrid(t *testing.T) {
+	box, err := Build(Grid(
+		Columns("20px", "1fr"),
+		Rows("10px", "10px"),
+		Canvas().Span(2, 1),
+		Canvas(),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Box{
+		Tag:   "grid",
+		Attrs: map[string]string{"columns": "20px 1fr", "rows": "10px 10px"},
+		Children: []*Box{
+			{Tag: "canvas", Attrs: map[string]string{"colSpan": "2", "rowSpan": "1"}},
+			{Tag: "canvas"},
+		},
+	}
+	if err := box.diff(want); err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(100, 100)
+	box.relayout()
+	if box.grid == nil || box.Children[0].Style.ColSpan != 2 {
+		t.Fatal("builder grid was not laid out as a grid")
+	}
+	checkPositions(t, box, [][2]int{{0, 0}, {0, 10}})
+}
+
+func TestBuilderStack(t *testing.T) {
+	box, err := Build(Stack(
+		Canvas(Attr("minWidth", "30px"), Attr("minHeight", "30px")),
+		Canvas(Attr("minWidth", "10px"), Attr("minHeight", "10px")).JustifySelf(End, End),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Box{
+		Tag: "stack",
+		Children: []*Box{
+			{Tag: "canvas", Attrs: map[string]string{"minWidth": "30px", "minHeight": "30px"}},
+			{Tag: "canvas", Attrs: map[string]string{"minWidth": "10px", "minHeight": "10px", "justifySelf": "end end"}},
+		},
+	}
+	if err := box.diff(want); err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(100, 100)
+	box.relayout()
+	checkPositions(t, box, [][2]int{{0, 0}, {20, 20}})
+}
+
+func TestBuilderList(t *testing.T) {
+	var clicked string
+	row := Canvas(Attr("minWidth", "40px"), Attr("minHeight", "20px"), Attr("name", "${item}")).OnClick(func(e *Event) bool {
+		clicked = e.Box.Attrs["name"]
+		return true
+	})
+	box, err := Build(List([]string{"a", "b", "c", "d"}, row, Attr("maxHeight", "40px")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(100, 100)
+	box.relayout()
+	checkPositions(t, box, [][2]int{{0, 0}, {0, 20}, {0, 40}})
+	if got := box.Children[1].Attrs["name"]; got != "b" {
+		t.Fatalf("got second row %q, want b", got)
+	}
+	if !box.Children[1].fireEvent(Click, "", nil, nil) || clicked != "b" {
+		t.Fatalf("got clicked %q, want the handler of the row template called for b", clicked)
+	}
+}
+
+func TestElementMarkup(t *testing.T) {
+	markup, err := Col(Text("Hello")).Markup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if markup == "" {
+		t.Fatal("got no markup for a valid element")
+	}
+	if _, err := Col(Attr("grow", "x"), Text("Hello")).Markup(); err == nil {
+		t.Fatal("got no error for an invalid attribute")
+	}
+}
@@ -0,0 +1,200 @@
+package bento
+
+import (
+	"image"
+	"reflect"
+
+	"github.com/etherealmachine/bento/text"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+)
+
+// the most text measurements kept for a box, enough for its content at a few widths
+const maxMeasurements = 8
+
+// layout results carried over from the previous build of a box, which are reused while its content, style and
+// constraints don't change
+type layoutCache struct {
+	changed     bool // the box or one of its descendants differs from the previous build
+	valid       bool // the size pass has run at least once with the constraints
+	restored    bool // the size pass restored the subtree in this layout
+	reused      bool // the grow pass restored the subtree in this layout
+	constraints constraints
+	sized       layout // the layout of the box after the last size pass
+	final       layout // the layout of the box when it was rebuilt
+	measured    map[measureKey]image.Point
+}
+
+// everything outside of the box that its size depends on
+type constraints struct {
+	availWidth, availHeight int
+	basisWidth, basisHeight int
+	viewWidth, viewHeight   int
+	scale                   float64
+	aspectMin               image.Point
+}
+
+type measureKey struct {
+	face      font.Face
+	text      string
+	width     int
+	paragraph bool
+}
+
+// carry the layout of the previous build over to the box, marking it changed if anything that affects its layout
+// differs, the children are compared once they are built
+// a box is changed when its tag, content or parsed style differ, which covers styles returned by subcomponents and
+// images set on the style, while changes made to the style of a built box are only seen after it is built again
+// lists are always changed since they build and lay out rows as they scroll
+func (n *Box) reuseLayout(prev *Box) {
+	if prev == nil || prev.Tag != n.Tag {
+		n.cache = layoutCache{changed: true}
+		return
+	}
+	n.cache = prev.cache
+	n.cache.final = prev.layout
+	n.breaks = prev.breaks
+	// restored boxes aren't sized again, so they keep the pixels their relative lengths resolved to
+	for f, l := range n.Style.relative {
+		if prev.Style.relative[f] == l {
+			field, _ := n.Style.lengthField(f)
+			resolved, _ := prev.Style.lengthField(f)
+			*field = *resolved
+		}
+	}
+	n.cache.changed = n.Tag == "list" || n.Content != prev.Content || !sameLayoutStyle(n.Style, prev.Style)
+}
+
+// mark the box changed if its children differ from the children of its previous build
+func (n *Box) compareChildren(prev *Box) {
+	if prev == nil || len(n.Children) != len(prev.Children) {
+		n.cache.changed = true
+	}
+	for _, c := range n.Children {
+		if c.cache.changed {
+			n.cache.changed = true
+		}
+	}
+}
+
+func sameAttrs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// whether two parsed styles lay a box out the same way
+// images and nine slices are loaded again on every build, so they are compared by size
+func sameLayoutStyle(a, b Style) bool {
+	if !sameAttrs(a.Attrs, b.Attrs) || a.Font != b.Font || imageSize(a.Image) != imageSize(b.Image) ||
+		!sameNineSlice(a.Border, b.Border) || !sameStates(a.Button, b.Button) || !sameStates(a.Input, b.Input) ||
+		(a.Scrollbar == nil) != (b.Scrollbar == nil) {
+		return false
+	}
+	if a.Scrollbar != nil {
+		for i := range a.Scrollbar {
+			if !sameStates(&a.Scrollbar[i], &b.Scrollbar[i]) {
+				return false
+			}
+		}
+	}
+	// the rest of the style is made of values, compared as a whole, except for the z index sort assigns
+	for _, s := range []*Style{&a, &b} {
+		s.Attrs, s.Font, s.Image, s.node = nil, nil, nil, nil
+		s.Border, s.Button, s.Input, s.Scrollbar = nil, nil, nil, nil
+		s.ZIndex = 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func imageSize(img *ebiten.Image) image.Point {
+	if img == nil {
+		return image.Point{}
+	}
+	return img.Bounds().Size()
+}
+
+func sameNineSlice(a, b *NineSlice) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.widths == b.widths && a.heights == b.heights && a.scale == b.scale
+}
+
+func sameStates(a, b *[4]*NineSlice) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	for i := range a {
+		if !sameNineSlice(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Box) constraints() constraints {
+	w, h := n.container()
+	vw, vh := n.viewport()
+	var bw, bh int
+	if n.Style.hasPercent() {
+		bw, bh = n.basis()
+	}
+	return constraints{w, h, bw, bh, vw, vh, n.Scale(), n.aspectMin}
+}
+
+// restore the layout of an unchanged subtree after the last size pass, if it was sized with the same constraints
+func (n *Box) restoreSize(c constraints) bool {
+	if n.cache.changed || !n.cache.valid || n.cache.constraints != c {
+		return false
+	}
+	n.visit(0, func(_ int, b *Box) error {
+		b.layout = b.cache.sized
+		b.cache.restored = true
+		b.cache.reused = false
+		return nil
+	})
+	return true
+}
+
+// restore the final layout of a restored subtree, if the box was given the same size as when it was rebuilt
+func (n *Box) restoreGrow() bool {
+	f := n.cache.final
+	if !n.cache.restored ||
+		n.ContentWidth != f.ContentWidth || n.ContentHeight != f.ContentHeight ||
+		n.InnerWidth != f.InnerWidth || n.InnerHeight != f.InnerHeight ||
+		n.OuterWidth != f.OuterWidth || n.OuterHeight != f.OuterHeight {
+		return false
+	}
+	n.visit(0, func(_ int, b *Box) error {
+		b.layout = b.cache.final
+		return nil
+	})
+	n.cache.reused = true
+	return true
+}
+
+// the size of the text in the font of the box, wrapped at width for paragraphs, measured once for each text and width
+func (n *Box) measure(txt string, width int, paragraph bool) image.Point {
+	key := measureKey{n.Style.Font, txt, width, paragraph}
+	if size, ok := n.cache.measured[key]; ok {
+		return size
+	}
+	var bounds image.Rectangle
+	if paragraph {
+		bounds = text.BoundParagraph(n.Style.Font, txt, width)
+	} else {
+		bounds = text.BoundString(n.Style.Font, txt)
+	}
+	if n.cache.measured == nil || len(n.cache.measured) >= maxMeasurements {
+		n.cache.measured = make(map[measureKey]image.Point)
+	}
+	n.cache.measured[key] = bounds.Size()
+	return bounds.Size()
+}
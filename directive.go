@@ -0,0 +1,212 @@
+package bento
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	placeholder = regexp.MustCompile(`\$\{(\w+(?:\.\w+)*)\}`)
+)
+
+// expand the directive attributes (if, each) of the box's children
+// if="false" removes the child, each="Items" as="item" repeats the child once per element of
+// the component's Items field, replacing ${item} and ${item.Field} in attributes and content
+func (n *Box) applyDirectives() error {
+	var children []*Box
+	for _, child := range n.Children {
+		if spec, ok := child.Attrs["each"]; ok {
+			clones, err := child.expandEach(n, spec)
+			if err != nil {
+				return err
+			}
+			for _, clone := range clones {
+				if keep, err := clone.evalIf(); err != nil {
+					return err
+				} else if keep {
+					children = append(children, clone)
+				}
+			}
+			continue
+		}
+		if keep, err := child.evalIf(); err != nil {
+			return err
+		} else if keep {
+			children = append(children, child)
+		}
+	}
+	n.Children = children
+	return nil
+}
+
+func (n *Box) evalIf() (bool, error) {
+	spec, ok := n.Attrs["if"]
+	if !ok {
+		return true, nil
+	}
+	keep, err := parseBool(spec)
+	if err != nil {
+		return false, fmt.Errorf("error parsing if on %s: %s", n.Tag, err)
+	}
+	return keep, nil
+}
+
+func (n *Box) expandEach(parent *Box, spec string) ([]*Box, error) {
+	name := n.Attrs["as"]
+	if name == "" {
+		name = "item"
+	}
+	items, err := lookup(reflect.ValueOf(parent.Component), spec)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating each=%q: %s", spec, err)
+	}
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return nil, fmt.Errorf("error evaluating each=%q: expected a slice or array, got %s", spec, items.Type())
+	}
+	var clones []*Box
+	for i := 0; i < items.Len(); i++ {
+		clone := n.clone(parent)
+		delete(clone.Attrs, "each")
+		delete(clone.Attrs, "as")
+		if err := clone.substitute(name, items.Index(i)); err != nil {
+			return nil, err
+		}
+		clones = append(clones, clone)
+	}
+	return clones, nil
+}
+
+func (n *Box) clone(parent *Box) *Box {
+	c := &Box{
+		Tag:       n.Tag,
+		Parent:    parent,
+		Content:   n.Content,
+		Component: n.Component,
+		Attrs:     make(map[string]string),
+		handlers:  n.handlers,
+	}
+	for k, v := range n.Attrs {
+		c.Attrs[k] = v
+	}
+	for _, child := range n.Children {
+		c.Children = append(c.Children, child.clone(c))
+	}
+	return c
+}
+
+// copy the box into dst, reusing the boxes of dst and its descendants in place of new ones
+// nothing dst held before is kept, so it starts over as a fresh copy
+func (n *Box) cloneInto(dst, parent *Box) *Box {
+	attrs, children := dst.Attrs, dst.Children
+	for k := range attrs {
+		delete(attrs, k)
+	}
+	if attrs == nil {
+		attrs = make(map[string]string)
+	}
+	for k, v := range n.Attrs {
+		attrs[k] = v
+	}
+	*dst = Box{
+		Tag:       n.Tag,
+		Parent:    parent,
+		Content:   n.Content,
+		Component: n.Component,
+		Attrs:     attrs,
+		handlers:  n.handlers,
+		Children:  children[:0],
+	}
+	for i, child := range n.Children {
+		if i < len(children) {
+			dst.Children = append(dst.Children, child.cloneInto(children[i], dst))
+		} else {
+			dst.Children = append(dst.Children, child.clone(dst))
+		}
+	}
+	return dst
+}
+
+// replace placeholders for the named item in the attributes and content of the box and its children
+func (n *Box) substitute(name string, item reflect.Value) error {
+	var err error
+	replace := func(s string) string {
+		return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+			path := placeholder.FindStringSubmatch(match)[1]
+			if path != name && !strings.HasPrefix(path, name+".") {
+				return match
+			}
+			v, lookupErr := lookup(item, strings.TrimPrefix(strings.TrimPrefix(path, name), "."))
+			if lookupErr != nil {
+				err = fmt.Errorf("error evaluating %s: %s", match, lookupErr)
+				return match
+			}
+			return fmt.Sprint(v.Interface())
+		})
+	}
+	for k, v := range n.Attrs {
+		n.Attrs[k] = replace(v)
+	}
+	n.Content = replace(n.Content)
+	if err != nil {
+		return err
+	}
+	for _, child := range n.Children {
+		if err := child.substitute(name, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve a dot-separated path of fields, methods or map keys starting from v
+func lookup(v reflect.Value, path string) (reflect.Value, error) {
+	if path == "" {
+		return v, nil
+	}
+	for _, name := range strings.Split(path, ".") {
+		if !v.IsValid() {
+			return v, fmt.Errorf("can't evaluate %s of nil", name)
+		}
+		if m := v.MethodByName(name); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() > 0 {
+			v = m.Call(nil)[0]
+			continue
+		}
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return v, fmt.Errorf("can't evaluate %s of nil", name)
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			field := v.FieldByName(name)
+			if !field.IsValid() || !field.CanInterface() {
+				return field, fmt.Errorf("%s has no exported field or method named %s", v.Type(), name)
+			}
+			v = field
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return v, fmt.Errorf("can't evaluate %s of %s", name, v.Type())
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return v, fmt.Errorf("map has no key %s", name)
+			}
+		default:
+			return v, fmt.Errorf("can't evaluate %s of %s", name, v.Type())
+		}
+	}
+	return v, nil
+}
+
+func parseBool(spec string) (bool, error) {
+	switch strings.TrimSpace(spec) {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q, expected true or false", spec)
+}
@@ -12,13 +12,23 @@ import (
 )
 
 func (n *Box) Draw(img *ebiten.Image) {
+	if n.Parent == nil && n.transformed() {
+		n.drawTransformed(img)
+		return
+	}
+	n.draw(img)
+}
+
+func (n *Box) draw(img *ebiten.Image) {
 	if !n.Style.Display || n.Style.Hidden {
 		return
 	}
-	if n.Parent == nil && (n.target == nil || n.target.SubImage(image.Rect(0, 0, 0, 0)) == nil) {
-		n.target = img
-		n.relayout()
+	if n.Parent == nil {
+		n.resize(img)
 	}
+	// the box draws itself clipped to its bounds, and its children clipped to the bounds of clipping ancestors
+	clip := img
+	img = img.SubImage(n.Bounds()).(*ebiten.Image)
 	mt, ml := n.Style.Margin.Top, n.Style.Margin.Left
 	pt, pl := n.Style.Padding.Top, n.Style.Padding.Left
 
@@ -59,7 +69,7 @@ func (n *Box) Draw(img *ebiten.Image) {
 
 	switch n.Tag {
 	case "button", "text":
-		text.DrawString(img, n.Content, n.Style.Font, n.Style.Color, n.Style.Underline, n.ContentWidth, n.ContentHeight, text.Center, text.Center, -1, *op)
+		text.DrawString(img, n.label, n.Style.Font, n.Style.Color, n.Style.Underline, n.ContentWidth, n.ContentHeight, text.Center, text.Center, -1, *op)
 	case "p":
 		if n.Style.Scrollbar != nil {
 			n.scrollable.position = text.DrawParagraph(
@@ -80,7 +90,13 @@ func (n *Box) Draw(img *ebiten.Image) {
 		}
 	case "img":
 		imgOp := new(ebiten.DrawImageOptions)
-		imgOp.GeoM.Scale(n.Style.ScaleX, n.Style.ScaleY)
+		if n.Style.HGrow > 0 || n.Style.VGrow > 0 {
+			// images that grow are scaled to fill their content
+			bounds := n.Style.Image.Bounds()
+			imgOp.GeoM.Scale(float64(n.ContentWidth)/float64(bounds.Dx()), float64(n.ContentHeight)/float64(bounds.Dy()))
+		} else {
+			imgOp.GeoM.Scale(n.Style.ScaleX, n.Style.ScaleY)
+		}
 		imgOp.GeoM.Translate(float64(n.X), float64(n.Y))
 		imgOp.GeoM.Translate(float64(ml), float64(mt))
 		imgOp.GeoM.Translate(float64(pl), float64(pt))
@@ -103,7 +119,7 @@ func (n *Box) Draw(img *ebiten.Image) {
 				n.drawScrollbar(img, op)
 			}
 		}
-	case "canvas", "row", "col":
+	case "canvas", "row", "col", "grid", "list", "stack":
 	default:
 		log.Fatalf("can't draw %s", n.Tag)
 	}
@@ -122,19 +138,26 @@ func (n *Box) Draw(img *ebiten.Image) {
 			n.OuterWidth, n.OuterHeight, text.Start, text.Start, -1, *op)
 	}
 
+	children := clip
+	if n.Style.clips() {
+		children = clip.SubImage(n.innerRect()).(*ebiten.Image)
+	}
 	for _, c := range n.Children {
-		c.Draw(img.SubImage(c.Bounds()).(*ebiten.Image))
+		c.Draw(children)
+	}
+	if n.Style.Overflow == OverflowScroll && n.Style.Scrollbar != nil && n.Tag != "p" && n.Tag != "textarea" {
+		n.drawViewportScrollbars(img)
 	}
 
 	if debug && n.Parent == nil {
 		op := new(ebiten.DrawImageOptions)
-		op.GeoM.Translate(float64(img.Bounds().Dx()-48), 24)
+		op.GeoM.Translate(float64(clip.Bounds().Dx()-48), 24)
 		txt := fmt.Sprintf("%.0f", ebiten.CurrentFPS())
 		font := text.Font("RobotoMono", 24)
 		bounds := text.BoundString(font, txt)
-		drawBox(img, bounds.Dx(), bounds.Dy(), color.White, false, op)
+		drawBox(clip, bounds.Dx(), bounds.Dy(), color.White, false, op)
 		text.DrawString(
-			img,
+			clip,
 			fmt.Sprintf("%.0f", ebiten.CurrentFPS()),
 			font, color.Black, false,
 			0, 0, text.Start, text.Start, -1, *op)
@@ -160,6 +183,22 @@ func (n *Box) drawScrollbar(img *ebiten.Image, op *ebiten.DrawImageOptions) {
 	}
 }
 
+func (n *Box) drawViewportScrollbars(img *ebiten.Image) {
+	rects, visible := n.viewportScrollRects()
+	states := [2][4]State{n.scrollable.state, n.scrollable.hstate}
+	op := new(ebiten.DrawImageOptions)
+	for axis, bar := range rects {
+		if !visible[axis] {
+			continue
+		}
+		for i, r := range bar {
+			if !r.Empty() {
+				n.Style.Scrollbar[states[axis][i]][i].Draw(img, r.Min.X, r.Min.Y, r.Dx(), r.Dy(), op)
+			}
+		}
+	}
+}
+
 func (n *Box) scrollRects(scrollPos float64) [4]image.Rectangle {
 	var rects [4]image.Rectangle
 	s := n.Style.Scrollbar[0][0].Width()
@@ -16,6 +16,7 @@ const (
 	Change = EventType("Change")
 	Draw   = EventType("Draw")
 	Update = EventType("Update")
+	Resize = EventType("Resize")
 )
 
 type Event struct {
@@ -26,10 +27,11 @@ type Event struct {
 	Image            *ebiten.Image            // Canvas element
 	Op               *ebiten.DrawImageOptions // Canvas element
 	Value            string                   // Input and Textarea elements
+	Width, Height    int                      // Resize event, the new size of the image the UI is drawn on
 }
 
 func (n *Box) fireEvent(e EventType, value string, img *ebiten.Image, op *ebiten.DrawImageOptions) bool {
-	x, y := ebiten.CursorPosition()
+	x, y, _ := n.cursor()
 	sx, sy := ebiten.Wheel()
 	return n.call("on"+string(e), &Event{
 		X:       x - n.X,
@@ -44,7 +46,60 @@ func (n *Box) fireEvent(e EventType, value string, img *ebiten.Image, op *ebiten
 	})
 }
 
+// EventHandlers can be implemented by a component to bind handler names in attributes, e.g. onClick="Save",
+// to closures instead of looking up a method with the same name
+type EventHandlers interface {
+	Handlers() map[string]func(*Event) bool
+}
+
+// Handle registers a closure on the root box for the handler name, which any box in the tree can refer to in its
+// event attributes. Registered handlers take precedence over methods of the component.
+func (n *Box) Handle(name string, fn func(*Event) bool) {
+	root := n.root()
+	if root.registry == nil {
+		root.registry = make(map[string]func(*Event) bool)
+	}
+	root.registry[name] = fn
+}
+
+func (n *Box) handler(attr string) func(*Event) bool {
+	if fn := n.handlers[attr]; fn != nil {
+		return fn
+	}
+	name := n.Attrs[attr]
+	if name == "" {
+		return nil
+	}
+	if fn := n.bound[name]; fn != nil {
+		return fn
+	}
+	return n.root().registry[name]
+}
+
+// bind the handlers of the component once when the box is built, sharing them with boxes of the same component
+func (n *Box) bindHandlers() {
+	c, ok := n.Component.(EventHandlers)
+	if !ok {
+		return
+	}
+	if n.Parent != nil && n.Parent.Component == n.Component && n.Parent.bound != nil {
+		n.bound = n.Parent.bound
+		return
+	}
+	n.bound = c.Handlers()
+}
+
 func (n *Box) call(attr string, args ...interface{}) bool {
+	if fn := n.handler(attr); fn != nil {
+		var event *Event
+		if len(args) > 0 {
+			event, _ = args[0].(*Event)
+		}
+		if fn(event) {
+			n.root().dirty = true
+		}
+		return true
+	}
 	fnName := n.Attrs[attr]
 	if fnName == "" {
 		return false
@@ -0,0 +1,179 @@
+package bento
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type HandlerComponent struct {
+	Saves, Closes, Resets int
+	Bindings              int
+}
+
+func (c *HandlerComponent) Handlers() map[string]func(*Event) bool {
+	c.Bindings++
+	return map[string]func(*Event) bool{
+		"Save": func(_ *Event) bool {
+			c.Saves++
+			return false
+		},
+	}
+}
+
+func (c *HandlerComponent) Reset() {
+	c.Resets++
+}
+
+func (c *HandlerComponent) UI() string {
+	return `<col>
+		<button onClick="Save">Save</button>
+		<button onClick="Close">Close</button>
+		<button onClick="Reset">Reset</button>
+	</col>`
+}
+
+func TestHandlers(t *testing.T) {
+	c := &HandlerComponent{}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.Handle("Close", func(_ *Event) bool {
+		c.Closes++
+		return true
+	})
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	for _, child := range box.Children {
+		if !child.fireEvent(Click, "", nil, nil) {
+			t.Fatalf("%s handler was not called", child.Content)
+		}
+	}
+	if c.Saves != 1 || c.Closes != 1 || c.Resets != 1 {
+		t.Fatalf("got %d saves, %d closes, %d resets, want 1 of each", c.Saves, c.Closes, c.Resets)
+	}
+	if c.Bindings != 2 {
+		t.Fatalf("got %d calls to Handlers, want one for each build", c.Bindings)
+	}
+}
+
+type ResizeComponent struct {
+	Sizes [][2]int
+}
+
+func (c *ResizeComponent) Resized(e *Event) {
+	c.Sizes = append(c.Sizes, [2]int{e.Width, e.Height})
+}
+
+func (c *ResizeComponent) UI() string {
+	return `<col grow="1" onResize="Resized"><canvas minWidth="10px" minHeight="10px" /></col>`
+}
+
+func TestResize(t *testing.T) {
+	c := &ResizeComponent{}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.Draw(ebiten.NewImage(100, 50))
+	box.Draw(ebiten.NewImage(100, 50))
+	box.Draw(ebiten.NewImage(200, 80))
+	if len(c.Sizes) != 2 || c.Sizes[0] != [2]int{100, 50} || c.Sizes[1] != [2]int{200, 80} {
+		t.Fatalf("got resize events %v, want one for each new size", c.Sizes)
+	}
+	if box.OuterWidth != 200 || box.OuterHeight != 80 {
+		t.Fatalf("got %dx%d, want the root laid out again at 200x80", box.OuterWidth, box.OuterHeight)
+	}
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	box.Draw(ebiten.NewImage(200, 80))
+	if len(c.Sizes) != 2 {
+		t.Fatalf("got resize events %v, want none after rebuilding", c.Sizes)
+	}
+}
+
+func TestVirtualResolution(t *testing.T) {
+	for _, tc := range []struct {
+		mode         ScaleMode
+		size, cursor [2]int
+	}{
+		{Letterbox, [2]int{200, 100}, [2]int{100, 50}},
+		{Fit, [2]int{200, 200}, [2]int{100, 100}},
+		{Fill, [2]int{200, 100}, [2]int{100, 50}},
+	} {
+		c := &ResizeComponent{}
+		box, err := Build(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		box.SetVirtualResolution(200, 100, tc.mode)
+		box.Draw(ebiten.NewImage(400, 400))
+		if box.OuterWidth != tc.size[0] || box.OuterHeight != tc.size[1] {
+			t.Errorf("mode %d got %dx%d, want %dx%d", tc.mode, box.OuterWidth, box.OuterHeight, tc.size[0], tc.size[1])
+		}
+		if x, y, _ := box.Children[0].toUI(200, 200); x != tc.cursor[0] || y != tc.cursor[1] {
+			t.Errorf("mode %d mapped the center of the screen to (%d,%d), want (%d,%d)", tc.mode, x, y, tc.cursor[0], tc.cursor[1])
+		}
+	}
+	box, err := Build(&ResizeComponent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var geom ebiten.GeoM
+	geom.Scale(2, 2)
+	geom.Translate(10, 20)
+	box.SetTransform(geom)
+	box.Draw(ebiten.NewImage(400, 400))
+	if x, y, _ := box.toUI(30, 60); x != 10 || y != 20 {
+		t.Errorf("got (%d,%d), want the transform inverted to (10,20)", x, y)
+	}
+	// nothing on an empty screen or through a singular transform maps back to the UI
+	for _, mode := range []ScaleMode{Letterbox, Fit, Fill} {
+		box.SetVirtualResolution(200, 100, mode)
+		if _, _, ok := box.fitScreen(image.Point{}); ok {
+			t.Errorf("mode %d fit the UI onto an empty screen", mode)
+		}
+		if _, _, ok := box.toUI(0, 0); ok {
+			t.Errorf("mode %d got a hit on an empty screen", mode)
+		}
+	}
+	geom.Reset()
+	geom.Scale(0, 1)
+	box.SetTransform(geom)
+	box.Draw(ebiten.NewImage(400, 400))
+	if _, _, ok := box.toUI(0, 0); ok {
+		t.Errorf("got a hit through a singular transform")
+	}
+}
+
+func TestPointer(t *testing.T) {
+	box, err := Build(&ResizeComponent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	surface := ebiten.NewImage(100, 50)
+	box.Draw(surface)
+	var geom ebiten.GeoM
+	geom.Scale(2, 2)
+	geom.Translate(300, 100)
+	box.SetPointer(GeoMPointer(geom, 100, 50))
+	if x, y, ok := box.Children[0].toUI(320, 140); !ok || x != 10 || y != 20 {
+		t.Errorf("got (%d,%d,%t), want (10,20) over the surface", x, y, ok)
+	}
+	if _, _, ok := box.toUI(10, 10); ok {
+		t.Errorf("got a hit outside the surface")
+	}
+	geom.Scale(0, 0)
+	box.SetPointer(GeoMPointer(geom, 100, 50))
+	if _, _, ok := box.toUI(0, 0); ok {
+		t.Errorf("got a hit through a singular transform")
+	}
+	box.SetPointer(func(_, _ int) (int, int, bool) { return 0, 0, false })
+	if _, _, ok := box.toUI(320, 140); ok {
+		t.Errorf("got a hit when the pointer function reports none")
+	}
+}
@@ -41,7 +41,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 }
 
 func (g *Game) Layout(ow, oh int) (int, int) {
-	return ow, oh
+	return g.ui.Layout(ow, oh)
 }
 
 func main() {
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.2.5
 	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
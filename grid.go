@@ -0,0 +1,254 @@
+package bento
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// A Track is a column or row of a grid
+// Tracks have either a fixed Size in pixels, a Fraction of the leftover space (e.g. "2fr"), or neither for "auto"
+type Track struct {
+	Size     int
+	Fraction int
+}
+
+type gridLayout struct {
+	columns, rows []int
+	cells         []gridCell
+}
+
+type gridCell struct {
+	box                        *Box
+	row, col, rowSpan, colSpan int
+}
+
+// parse track spec e.g. "100px 1fr 2fr auto"
+func parseTracks(spec string, f font.Face, scale float64) ([]Track, error) {
+	var tracks []Track
+	for _, s := range strings.Fields(spec) {
+		switch {
+		case s == "auto":
+			tracks = append(tracks, Track{})
+		case strings.HasSuffix(s, "fr"):
+			fr, err := strconv.Atoi(strings.TrimSuffix(s, "fr"))
+			if err != nil || fr <= 0 {
+				return nil, fmt.Errorf("invalid track %s", s)
+			}
+			tracks = append(tracks, Track{Fraction: fr})
+		case sizeSpec.MatchString(s):
+			size, err := parseSize(s, f, scale)
+			if err != nil {
+				return nil, err
+			}
+			tracks = append(tracks, Track{Size: size})
+		default:
+			return nil, fmt.Errorf("invalid track %s", s)
+		}
+	}
+	return tracks, nil
+}
+
+// place the children in the grid, left to right and top to bottom, in the first cells where they fit
+func (n *Box) placeGrid() {
+	ncols := max(len(n.Style.Columns), 1)
+	g := &gridLayout{}
+	var occupied [][]bool
+	free := func(row, col, rowSpan, colSpan int) bool {
+		if col+colSpan > ncols {
+			return false
+		}
+		for r := row; r < row+rowSpan && r < len(occupied); r++ {
+			for c := col; c < col+colSpan; c++ {
+				if occupied[r][c] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	row, col := 0, 0
+	for _, c := range n.flow() {
+		cell := gridCell{
+			box:     c,
+			rowSpan: max(c.Style.RowSpan, 1),
+			colSpan: max(min(c.Style.ColSpan, ncols), 1),
+		}
+		for !free(row, col, cell.rowSpan, cell.colSpan) {
+			col++
+			if col >= ncols {
+				col = 0
+				row++
+			}
+		}
+		cell.row, cell.col = row, col
+		for len(occupied) < row+cell.rowSpan {
+			occupied = append(occupied, make([]bool, ncols))
+		}
+		for r := row; r < row+cell.rowSpan; r++ {
+			for c := col; c < col+cell.colSpan; c++ {
+				occupied[r][c] = true
+			}
+		}
+		g.cells = append(g.cells, cell)
+		col += cell.colSpan
+	}
+	g.columns = make([]int, ncols)
+	g.rows = make([]int, max(len(n.Style.Rows), len(occupied)))
+	n.grid = g
+}
+
+// determine the minimum size of each track, and the content size of the grid
+func (n *Box) sizeGrid() {
+	n.placeGrid()
+	g := n.grid
+	sizeTracks(g.columns, n.Style.Columns, n.Style.HGap, g.cells, func(c gridCell) (int, int, int) {
+		return c.col, c.colSpan, c.box.OuterWidth
+	})
+	sizeTracks(g.rows, n.Style.Rows, n.Style.VGap, g.cells, func(c gridCell) (int, int, int) {
+		return c.row, c.rowSpan, c.box.OuterHeight
+	})
+	n.ContentWidth = span(g.columns, 0, len(g.columns), n.Style.HGap)
+	n.ContentHeight = span(g.rows, 0, len(g.rows), n.Style.VGap)
+}
+
+func sizeTracks(sizes []int, tracks []Track, gap int, cells []gridCell, extent func(gridCell) (int, int, int)) {
+	fixed := func(i int) bool {
+		return i < len(tracks) && tracks[i].Size > 0
+	}
+	for i := range sizes {
+		if fixed(i) {
+			sizes[i] = tracks[i].Size
+		}
+	}
+	for _, c := range cells {
+		if start, count, size := extent(c); count == 1 && !fixed(start) {
+			sizes[start] = max(sizes[start], size)
+		}
+	}
+	// spread whatever doesn't fit of children spanning multiple tracks evenly over the flexible tracks
+	for _, c := range cells {
+		start, count, size := extent(c)
+		if count == 1 {
+			continue
+		}
+		size -= totalGap(count, gap)
+		var flexible []int
+		for i := start; i < start+count; i++ {
+			size -= sizes[i]
+			if !fixed(i) {
+				flexible = append(flexible, i)
+			}
+		}
+		for j, i := range flexible {
+			if size <= 0 {
+				break
+			}
+			extra := size / (len(flexible) - j)
+			sizes[i] += extra
+			size -= extra
+		}
+	}
+}
+
+// distribute the space the grid grew by to its fractional tracks, then grow the children to fill their cells
+func (n *Box) growGrid() {
+	g := n.grid
+	if g == nil {
+		return
+	}
+	fitTracks(g.columns, n.Style.Columns, n.ContentWidth-totalGap(len(g.columns), n.Style.HGap))
+	fitTracks(g.rows, n.Style.Rows, n.ContentHeight-totalGap(len(g.rows), n.Style.VGap))
+	for _, c := range g.cells {
+		if c.box.Style.HGrow > 0 || c.box.Style.HJustSelf == Stretch {
+			c.box.fillWidth(span(g.columns, c.col, c.colSpan, n.Style.HGap))
+		}
+		if c.box.Style.VGrow > 0 || c.box.Style.VJustSelf == Stretch {
+			c.box.fillHeight(span(g.rows, c.row, c.rowSpan, n.Style.VGap))
+		}
+	}
+}
+
+// fractional tracks share the space not taken by other tracks in proportion to their fraction,
+// without shrinking below their minimum size
+func fitTracks(sizes []int, tracks []Track, size int) {
+	flexible := make(map[int]bool)
+	for i := range sizes {
+		if i < len(tracks) && tracks[i].Fraction > 0 {
+			flexible[i] = true
+		}
+	}
+	for len(flexible) > 0 {
+		space, fractions := size, 0
+		for i := range sizes {
+			if flexible[i] {
+				fractions += tracks[i].Fraction
+			} else {
+				space -= sizes[i]
+			}
+		}
+		if space <= 0 {
+			return
+		}
+		// tracks whose minimum exceeds their share keep their minimum, and the rest is shared again
+		done := true
+		for i := range flexible {
+			if share := space * tracks[i].Fraction / fractions; sizes[i] > share {
+				delete(flexible, i)
+				done = false
+			}
+		}
+		if done {
+			for i := range flexible {
+				sizes[i] = space * tracks[i].Fraction / fractions
+			}
+			return
+		}
+	}
+}
+
+// place the children in their cells in the content box according to their own justification
+func (n *Box) justifyGrid() {
+	g := n.grid
+	if g == nil {
+		return
+	}
+	p := n.Style.Padding
+	for _, c := range g.cells {
+		x, y := p.Left+sum(g.columns[:c.col])+c.col*n.Style.HGap, p.Top+sum(g.rows[:c.row])+c.row*n.Style.VGap
+		w, h := span(g.columns, c.col, c.colSpan, n.Style.HGap), span(g.rows, c.row, c.rowSpan, n.Style.VGap)
+		c.box.X += x + align(c.box.Style.HJustSelf, w, c.box.OuterWidth)
+		c.box.Y += y + align(c.box.Style.VJustSelf, h, c.box.OuterHeight)
+	}
+}
+
+// offset of an item of the given size aligned in space
+func align(j Justification, space, size int) int {
+	switch j {
+	case End:
+		return space - size
+	case Center:
+		return space/2 - size/2
+	}
+	return 0
+}
+
+// the size of n tracks starting at i, including the gaps between them
+func span(tracks []int, i, n, gap int) int {
+	return sum(tracks[i:i+n]) + totalGap(n, gap)
+}
+
+// the total size of the gaps between n items
+func totalGap(n, gap int) int {
+	return max(n-1, 0) * gap
+}
+
+func sum(a []int) int {
+	total := 0
+	for _, x := range a {
+		total += x
+	}
+	return total
+}
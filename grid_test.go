@@ -0,0 +1,77 @@
+package bento
+
+import (
+	"testing"
+)
+
+func TestParseTracks(t *testing.T) {
+	got, err := parseTracks("100px 1fr 2fr auto", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Track{{Size: 100}, {Fraction: 1}, {Fraction: 2}, {}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	for _, spec := range []string{"1", "0fr", "foo"} {
+		if _, err := parseTracks(spec, nil, 1); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestGrid(t *testing.T) {
+	box := layoutTree(t, `<grid columns="20px 1fr 2fr" grow="1 0">
+		<canvas minWidth="10px" minHeight="10px" />
+		<canvas minWidth="10px" minHeight="10px" colSpan="2" />
+		<canvas minWidth="10px" minHeight="30px" rowSpan="2" />
+		<canvas minWidth="10px" minHeight="10px" justifySelf="center" />
+		<canvas minWidth="10px" minHeight="10px" justifySelf="end end" grow="0 1" />
+		<canvas minWidth="10px" minHeight="10px" />
+	</grid>`, 110, 100)
+	g := box.grid
+	if want := []int{20, 30, 60}; !equal(g.columns, want) {
+		t.Fatalf("got columns %v, want %v", g.columns, want)
+	}
+	if want := []int{10, 15, 15}; !equal(g.rows, want) {
+		t.Fatalf("got rows %v, want %v", g.rows, want)
+	}
+	checkPositions(t, box, [][2]int{
+		{0, 0},
+		{20, 0},
+		{0, 10},
+		{30, 12},
+		{100, 10},
+		{20, 25},
+	})
+	if h := box.Children[4].OuterHeight; h != 15 {
+		t.Fatalf("got height %d, want growing child to fill its cell", h)
+	}
+
+	box = layoutTree(t, `<grid columns="20px 20px" padding="10px">
+		<canvas minWidth="10px" minHeight="10px" />
+		<canvas minWidth="10px" minHeight="10px" justifySelf="end" />
+		<canvas minWidth="10px" minHeight="10px" />
+	</grid>`, 100, 100)
+	checkPositions(t, box, [][2]int{{10, 10}, {40, 10}, {10, 20}})
+	if box.OuterWidth != 60 || box.OuterHeight != 40 {
+		t.Fatalf("got size %dx%d, want 60x40", box.OuterWidth, box.OuterHeight)
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
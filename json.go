@@ -0,0 +1,66 @@
+package bento
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// markup is the JSON and YAML representation of a box, e.g.
+// {"tag": "col", "attrs": {"margin": "24px"}, "children": [{"tag": "text", "content": "Hello"}]}
+type markup struct {
+	Tag      string                 `json:"tag" yaml:"tag"`
+	Attrs    map[string]interface{} `json:"attrs" yaml:"attrs"`
+	Content  string                 `json:"content" yaml:"content"`
+	Children []*markup              `json:"children" yaml:"children"`
+}
+
+func (n *Box) UnmarshalJSON(data []byte) error {
+	m := new(markup)
+	if err := json.Unmarshal(data, m); err != nil {
+		return err
+	}
+	return n.fromMarkup(m, "$")
+}
+
+func (n *Box) UnmarshalYAML(value *yaml.Node) error {
+	m := new(markup)
+	if err := value.Decode(m); err != nil {
+		return err
+	}
+	return n.fromMarkup(m, "$")
+}
+
+func (n *Box) fromMarkup(m *markup, path string) error {
+	if m == nil {
+		return fmt.Errorf("%s: missing box", path)
+	}
+	if m.Tag == "" {
+		return fmt.Errorf("%s.tag: missing tag", path)
+	}
+	if err := checkTag(m.Tag); err != nil {
+		return fmt.Errorf("%s.tag: %s", path, err)
+	}
+	n.Tag = m.Tag
+	n.Content = m.Content
+	n.Attrs = make(map[string]string)
+	for k, v := range m.Attrs {
+		switch v := v.(type) {
+		case string:
+			n.Attrs[k] = v
+		case float64, int, bool:
+			n.Attrs[k] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("%s.attrs.%s: expected a string, number or boolean, got %T", path, k, v)
+		}
+	}
+	for i, c := range m.Children {
+		child := &Box{Parent: n}
+		if err := child.fromMarkup(c, fmt.Sprintf("%s.children[%d]", path, i)); err != nil {
+			return err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return nil
+}
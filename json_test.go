@@ -0,0 +1,91 @@
+package bento
+
+import (
+	"strings"
+	"testing"
+)
+
+type JSONComponent struct {
+	Count int
+}
+
+func (c *JSONComponent) UI() string {
+	return `{"tag": "col", "attrs": {"margin": "24px", "zIndex": 2}, "children": [
+		{"tag": "row", "children": [{"tag": "text", "content": "{{ .Count }}"}]},
+		{"tag": "input", "attrs": {"value": "{{ .Count }}"}}
+	]}`
+}
+
+type YAMLComponent struct {
+	Count int
+}
+
+func (c *YAMLComponent) UI() string {
+	return `
+tag: col
+attrs:
+  margin: 24px
+  zIndex: 2
+children:
+  - tag: row
+    children:
+      - tag: text
+        content: "{{ .Count }}"
+  - tag: input
+    attrs:
+      value: "{{ .Count }}"
+`
+}
+
+type XMLComponent struct {
+	Count int
+}
+
+func (c *XMLComponent) UI() string {
+	return `<col margin="24px" zIndex="2">
+		<row>
+			<text>{{ .Count }}</text>
+		</row>
+		<input value="{{ .Count }}" />
+	</col>`
+}
+
+func TestBuildJSONAndYAML(t *testing.T) {
+	want, err := Build(&XMLComponent{Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []Component{&JSONComponent{Count: 3}, &YAMLComponent{Count: 3}} {
+		got, err := Build(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := got.diff(want); err != nil {
+			t.Fatalf("%T: %s", c, err)
+		}
+		for _, child := range got.Children {
+			if child.Parent != got {
+				t.Fatalf("%T: child has incorrect parent", c)
+			}
+		}
+	}
+}
+
+type InvalidJSONComponent struct{}
+
+func (c *InvalidJSONComponent) UI() string {
+	return `{"tag": "col", "children": [
+		{"tag": "row"},
+		{"tag": "row", "children": [{"tag": "div"}]}
+	]}`
+}
+
+func TestBuildJSONError(t *testing.T) {
+	_, err := Build(&InvalidJSONComponent{})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported tag")
+	}
+	if want := "$.children[1].children[0].tag"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("got error %q, want it to contain %q", err, want)
+	}
+}
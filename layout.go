@@ -8,6 +8,7 @@ import (
 	"sort"
 
 	"github.com/etherealmachine/bento/text"
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type layout struct {
@@ -15,6 +16,15 @@ type layout struct {
 	ContentWidth, ContentHeight int `xml:",attr"`
 	InnerWidth, InnerHeight     int `xml:",attr"`
 	OuterWidth, OuterHeight     int `xml:",attr"`
+	availWidth, availHeight     int
+	scrollWidth, scrollHeight   int
+	label                       string // the content of text and buttons as drawn, ellipsized to fit
+}
+
+// a run of children laid out along the main axis of a row or col
+type line struct {
+	children    []*Box
+	main, cross int
 }
 
 func (n *Box) Bounds() image.Rectangle {
@@ -24,10 +34,53 @@ func (n *Box) Bounds() image.Rectangle {
 func (n *Box) relayout() {
 	n.size()
 	n.grow()
+	if n.fitAspects() {
+		n.size()
+		n.grow()
+		n.visit(0, func(_ int, b *Box) error {
+			b.aspectMin = image.Point{}
+			return nil
+		})
+	}
 	n.justify()
+	n.anchor()
 	n.sort()
 }
 
+// relayout the root when the size of the image it is drawn on changes, then fire onResize on every box
+func (n *Box) resize(img *ebiten.Image) {
+	prev := n.target
+	n.target = img
+	size := img.Bounds().Size()
+	if prev != nil && prev.SubImage(image.Rect(0, 0, 0, 0)) != nil && prev.Bounds().Size() == size {
+		return
+	}
+	n.relayout()
+	n.visit(0, func(_ int, b *Box) error {
+		b.call("onResize", &Event{
+			Type:   Resize,
+			Box:    b,
+			Width:  size.X,
+			Height: size.Y,
+		})
+		return nil
+	})
+}
+
+// Layout returns the size of the window in device pixels, to be returned from the Layout method of the game, e.g.
+// func (g *Game) Layout(w, h int) (int, int) { return g.ui.Layout(w, h) }
+// the screen and pointer input are then in device pixels, so the UI scale defaults to the device scale factor to keep
+// the UI the same size on screen, and the UI is rebuilt at that scale on the next update
+// it doesn't lay the UI out, Draw does that whenever the size of the image it draws on changes
+func (n *Box) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if root := n.root(); !root.deviceScaled {
+		root.deviceScaled = true
+		root.dirty = true
+	}
+	s := deviceScaleFactor()
+	return int(math.Ceil(float64(outsideWidth) * s)), int(math.Ceil(float64(outsideHeight) * s))
+}
+
 func (n *Box) outerRect() image.Rectangle {
 	return image.Rect(n.X, n.Y, n.X+n.OuterWidth, n.Y+n.OuterHeight)
 }
@@ -38,6 +91,10 @@ func (n *Box) innerRect() image.Rectangle {
 }
 
 func (n *Box) maxContentWidth() int {
+	// paragraphs that shrink wrap at their content width
+	if n.Style.HShrink > 0 {
+		return n.ContentWidth
+	}
 	if n.Style.MaxWidth > 0 {
 		return n.Style.MaxWidth
 	}
@@ -51,11 +108,26 @@ func (n *Box) maxContentHeight() int {
 	return n.ContentHeight
 }
 
-// determine the minimum size of the box
-// the box must expand to fit all of its children
+// determine the minimum size of the box, or restore it if nothing it depends on changed since the last layout
 func (n *Box) size() {
+	c := n.constraints()
+	if n.restoreSize(c) {
+		return
+	}
+	n.cache.restored, n.cache.reused = false, false
+	n.measureSize()
+	n.cache.sized = n.layout
+	n.cache.constraints = c
+	n.cache.valid = true
+}
+
+// the box must expand to fit all of its children
+func (n *Box) measureSize() {
 	n.ContentWidth = 0
 	n.ContentHeight = 0
+	n.applyBreakpoints()
+	n.resolveLengths()
+	n.availWidth, n.availHeight = n.available()
 	if !n.Style.Display {
 		n.InnerWidth = 0
 		n.InnerHeight = 0
@@ -64,13 +136,12 @@ func (n *Box) size() {
 		return
 	}
 	if n.Tag == "button" || n.Tag == "text" {
-		bounds := text.BoundString(n.Style.Font, n.Content)
-		n.ContentWidth = bounds.Dx()
+		n.ContentWidth = n.measure(n.Content, 0, false).X
 		n.ContentHeight = n.Style.Font.Metrics().Height.Ceil()
 	} else if n.Tag == "p" {
-		bounds := text.BoundParagraph(n.Style.Font, n.Content, n.Style.MaxWidth)
-		n.ContentWidth = bounds.Dx()
-		n.ContentHeight = max(bounds.Dy(), n.Style.Font.Metrics().Height.Ceil())
+		bounds := n.measure(n.Content, n.Style.MaxWidth, true)
+		n.ContentWidth = bounds.X
+		n.ContentHeight = max(bounds.Y, n.Style.Font.Metrics().Height.Ceil())
 	} else if n.Tag == "img" && n.Style.Image != nil {
 		bounds := n.Style.Image.Bounds()
 		n.ContentWidth = int(float64(bounds.Dx()) * n.Style.ScaleX)
@@ -80,32 +151,47 @@ func (n *Box) size() {
 		if txt == "" {
 			txt = n.Attrs["placeholder"]
 		}
-		bounds := text.BoundString(n.Style.Font, txt)
-		n.ContentWidth = bounds.Dx()
+		n.ContentWidth = n.measure(txt, 0, false).X
 		n.ContentHeight = n.Style.Font.Metrics().Height.Ceil()
 	} else if n.Tag == "textarea" {
 		txt := n.Content
 		if txt == "" {
 			txt = n.Attrs["placeholder"]
 		}
-		bounds := text.BoundParagraph(n.Style.Font, txt, n.Style.MaxWidth)
-		n.ContentWidth = bounds.Dx()
-		n.ContentHeight = max(bounds.Dy(), n.Style.Font.Metrics().Height.Ceil())
-	} else if n.Tag != "canvas" && n.Tag != "row" && n.Tag != "col" {
+		bounds := n.measure(txt, n.Style.MaxWidth, true)
+		n.ContentWidth = bounds.X
+		n.ContentHeight = max(bounds.Y, n.Style.Font.Metrics().Height.Ceil())
+	} else if n.Tag != "canvas" && n.Tag != "row" && n.Tag != "col" && n.Tag != "grid" && n.Tag != "list" && n.Tag != "stack" {
 		log.Fatalf("can't size %s", n.Tag)
 	}
-	for _, c := range n.Children {
-		c.size()
-		if c.Style.Float {
-			continue
+	if n.Tag == "list" {
+		n.sizeList()
+	} else {
+		for _, c := range n.Children {
+			c.size()
 		}
-		switch n.Tag {
-		case "row":
-			n.ContentWidth += c.OuterWidth
-			n.ContentHeight = max(n.ContentHeight, c.OuterHeight)
-		case "col":
+	}
+	switch n.Tag {
+	case "row":
+		lines := n.lines(n.availWidth)
+		for _, l := range lines {
+			n.ContentWidth = max(n.ContentWidth, l.main)
+			n.ContentHeight += l.cross
+		}
+		n.ContentHeight += totalGap(len(lines), n.Style.VGap)
+	case "col":
+		lines := n.lines(n.availHeight)
+		for _, l := range lines {
+			n.ContentWidth += l.cross
+			n.ContentHeight = max(n.ContentHeight, l.main)
+		}
+		n.ContentWidth += totalGap(len(lines), n.Style.HGap)
+	case "grid":
+		n.sizeGrid()
+	case "stack":
+		for _, c := range n.flow() {
 			n.ContentWidth = max(n.ContentWidth, c.OuterWidth)
-			n.ContentHeight += c.OuterHeight
+			n.ContentHeight = max(n.ContentHeight, c.OuterHeight)
 		}
 	}
 	n.styleSize()
@@ -127,35 +213,41 @@ func (n *Box) grow() {
 		if n.Style.VGrow > 0 {
 			n.fillHeight(h)
 		}
-	}
-	// sum the growth attributes to determine the relative growth of each child later
-	hgrow, vgrow := 0, 0
-	for _, c := range n.Children {
-		if !c.Style.Display || c.Style.Float {
-			continue
+		if n.Style.HShrink > 0 && n.OuterWidth > w {
+			n.shrink(true, w)
+		}
+		if n.Style.VShrink > 0 && n.OuterHeight > h {
+			n.shrink(false, h)
 		}
-		hgrow += c.Style.HGrow
-		vgrow += c.Style.VGrow
 	}
-	// allocate leftover space to each child according to their relative growth terms
-	hspace, vspace := n.space()
+	n.growAspect()
+	if n.restoreGrow() {
+		return
+	}
+	n.ellipsize()
+	n.resizeRelative()
 	for _, c := range n.Children {
-		if !c.Style.Display || c.Style.Float {
-			continue
+		if c.Style.Display && c.Style.Float {
+			if c.Style.HJustSelf == Stretch {
+				c.fillWidth(n.InnerWidth)
+			}
+			if c.Style.VJustSelf == Stretch {
+				c.fillHeight(n.InnerHeight)
+			}
 		}
-		if c.Style.HGrow > 0 {
-			if n.Tag == "row" {
-				halloc := int(math.Floor(float64(c.Style.HGrow) / float64(hgrow) * float64(hspace)))
-				c.fillWidth(c.OuterWidth + halloc)
-			} else {
+	}
+	if n.Tag == "row" || n.Tag == "col" {
+		n.growLines()
+	} else if n.Tag == "grid" {
+		n.growGrid()
+	} else if n.Tag == "list" {
+		n.growList()
+	} else {
+		for _, c := range n.flow() {
+			if c.Style.HGrow > 0 || c.Style.HJustSelf == Stretch {
 				c.fillWidth(n.ContentWidth)
 			}
-		}
-		if c.Style.VGrow > 0 && vgrow > 0 {
-			if n.Tag == "col" {
-				valloc := int(math.Floor(float64(c.Style.VGrow) / float64(vgrow) * float64(vspace)))
-				c.fillHeight(c.OuterHeight + valloc)
-			} else {
+			if c.Style.VGrow > 0 || c.Style.VJustSelf == Stretch {
 				c.fillHeight(n.ContentHeight)
 			}
 		}
@@ -169,15 +261,378 @@ func (n *Box) grow() {
 	}
 }
 
+// shorten the content of text and buttons that have shrunk narrower than it, once their size is final
+func (n *Box) ellipsize() {
+	if n.Tag != "text" && n.Tag != "button" {
+		return
+	}
+	n.label = n.Content
+	if n.measure(n.Content, 0, false).X > n.ContentWidth {
+		n.label = text.Ellipsize(n.Style.Font, n.Content, n.ContentWidth)
+	}
+}
+
+// size the children with percentages again, now that the content box they are relative to is known
+func (n *Box) resizeRelative() {
+	resized := false
+	for _, c := range n.Children {
+		if c.Style.Display && c.Style.hasPercent() {
+			c.size()
+			resized = true
+		}
+	}
+	if resized && n.Tag == "row" {
+		n.lines(n.availWidth)
+	} else if resized && n.Tag == "col" {
+		n.lines(n.availHeight)
+	}
+}
+
+// growth along one axis determines the other for boxes with an aspect ratio
+// boxes that grow along both axes fit within the space they were given
+func (n *Box) growAspect() {
+	if n.Style.Aspect <= 0 {
+		return
+	}
+	w, h := n.ContentWidth, n.ContentHeight
+	if n.Style.HGrow > 0 && n.Style.VGrow == 0 {
+		h = 0
+	} else if n.Style.VGrow > 0 && n.Style.HGrow == 0 {
+		w = 0
+	}
+	w, h = n.Style.aspectSize(w, h, n.Style.HGrow > 0 && n.Style.VGrow > 0)
+	p, m := n.Style.Padding, n.Style.Margin
+	n.ContentWidth, n.ContentHeight = w, h
+	n.InnerWidth = w + p.Left + p.Right
+	n.InnerHeight = h + p.Top + p.Bottom
+	n.OuterWidth = n.InnerWidth + m.Left + m.Right
+	n.OuterHeight = n.InnerHeight + m.Top + m.Bottom
+}
+
+// boxes with an aspect ratio that grow along one axis derive the other once their parent is sized, so a derived side
+// larger than the box was sized with is kept as its minimum, and its ancestors are sized again to fit it
+// returns whether the layout needs to run again
+func (n *Box) fitAspects() bool {
+	again := false
+	n.visit(0, func(_ int, b *Box) error {
+		s := &b.Style
+		if s.Aspect <= 0 || !s.Display || (s.HGrow > 0) == (s.VGrow > 0) {
+			return nil
+		}
+		var derived image.Point
+		if s.HGrow > 0 {
+			derived.Y = b.ContentHeight
+		} else {
+			derived.X = b.ContentWidth
+		}
+		if sized := b.cache.sized; derived.X <= sized.ContentWidth && derived.Y <= sized.ContentHeight {
+			return nil
+		}
+		b.aspectMin = derived
+		for p := b; p != nil; p = p.Parent {
+			p.cache.valid = false
+		}
+		again = true
+		return nil
+	})
+	return again
+}
+
+// allocate leftover space in each line to the children according to their relative growth terms
+func (n *Box) growLines() {
+	horizontal := n.Tag == "row"
+	contentMain, contentCross := n.ContentHeight, n.ContentWidth
+	if horizontal {
+		contentMain, contentCross = n.ContentWidth, n.ContentHeight
+	}
+	lines := n.wrapped()
+	for _, l := range lines {
+		cross := l.cross
+		if len(lines) == 1 {
+			cross = contentCross
+		}
+		space := contentMain - l.main
+		if space < 0 {
+			shrinkLine(l.children, horizontal, -space)
+		}
+		total := 0
+		for _, c := range l.children {
+			total += c.growFactor(horizontal)
+		}
+		for _, c := range l.children {
+			if g := c.growFactor(horizontal); g > 0 {
+				main, _ := c.extents(horizontal)
+				alloc := int(math.Floor(float64(g) / float64(total) * float64(space)))
+				c.fill(horizontal, main+alloc)
+			}
+			if c.growFactor(!horizontal) > 0 || n.stretches(c, !horizontal) {
+				c.fill(!horizontal, cross)
+			}
+			if _, c2 := c.extents(horizontal); c.shrinkFactor(!horizontal) > 0 && c2 > cross {
+				c.shrink(!horizontal, cross)
+			}
+		}
+	}
+}
+
+// remove overflow from the children of a line in proportion to their shrink factors
+// children that reach their minimum size stop shrinking, and the rest of the overflow is shared by the others
+func shrinkLine(children []*Box, horizontal bool, overflow int) {
+	var shrinking []*Box
+	for _, c := range children {
+		if c.shrinkFactor(horizontal) > 0 {
+			shrinking = append(shrinking, c)
+		}
+	}
+	for overflow > 0 && len(shrinking) > 0 {
+		total := 0
+		for _, c := range shrinking {
+			total += c.shrinkFactor(horizontal)
+		}
+		removed := 0
+		var next []*Box
+		for _, c := range shrinking {
+			main, _ := c.extents(horizontal)
+			cut := min(int(math.Ceil(float64(overflow)*float64(c.shrinkFactor(horizontal))/float64(total))), overflow-removed)
+			c.shrink(horizontal, main-cut)
+			size, _ := c.extents(horizontal)
+			removed += main - size
+			if size == main-cut {
+				next = append(next, c)
+			}
+		}
+		if removed == 0 {
+			return
+		}
+		overflow -= removed
+		shrinking = next
+	}
+}
+
+func (n *Box) shrinkFactor(horizontal bool) int {
+	if horizontal {
+		return n.Style.HShrink
+	}
+	return n.Style.VShrink
+}
+
+// shrink the box to the outer size along the axis, without shrinking its content below its min size
+// paragraphs rewrap to the narrower width
+func (n *Box) shrink(horizontal bool, size int) {
+	m, p := n.Style.Margin, n.Style.Padding
+	if horizontal {
+		n.ContentWidth = max(size-m.Left-m.Right-p.Left-p.Right, max(n.Style.MinWidth, 0))
+		n.InnerWidth = n.ContentWidth + p.Left + p.Right
+		n.OuterWidth = n.InnerWidth + m.Left + m.Right
+		if n.Tag == "p" || n.Tag == "textarea" {
+			n.ContentHeight = max(n.measure(n.Content, n.ContentWidth, true).Y, n.Style.Font.Metrics().Height.Ceil())
+			if n.Style.MaxHeight > 0 {
+				n.ContentHeight = min(n.ContentHeight, n.Style.MaxHeight)
+			}
+			n.InnerHeight = n.ContentHeight + p.Top + p.Bottom
+			n.OuterHeight = n.InnerHeight + m.Top + m.Bottom
+		}
+	} else {
+		n.ContentHeight = max(size-m.Top-m.Bottom-p.Top-p.Bottom, max(n.Style.MinHeight, 0))
+		n.InnerHeight = n.ContentHeight + p.Top + p.Bottom
+		n.OuterHeight = n.InnerHeight + m.Top + m.Bottom
+	}
+}
+
+// whether the child fills the box along the given axis, because either the box or the child justifies with stretch
+func (n *Box) stretches(c *Box, horizontal bool) bool {
+	if horizontal {
+		return n.Style.HJust == Stretch || c.Style.HJustSelf == Stretch
+	}
+	return n.Style.VJust == Stretch || c.Style.VJustSelf == Stretch
+}
+
+// children that take part in the flow layout of the box, in layout order
+// children are laid out by their order attribute, then in the order they were drawn, which doesn't change
+func (n *Box) flow() []*Box {
+	var children []*Box
+	for _, c := range n.Children {
+		if c.Style.Display && !c.Style.Float {
+			children = append(children, c)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Style.Order < children[j].Style.Order
+	})
+	return children
+}
+
+// split the flow children into lines no longer than limit along the main axis
+// boxes that don't wrap, or have no limit, put all of their children on a single line
+// the split is kept, so that grow and justify place the children on the same lines they were sized on
+func (n *Box) lines(limit int) []*line {
+	horizontal := n.Tag == "row"
+	gap, _ := n.gaps(horizontal)
+	var lines []*line
+	l := &line{}
+	for _, c := range n.flow() {
+		main, _ := c.extents(horizontal)
+		if n.Style.Wrap && limit > 0 && len(l.children) > 0 && l.main+gap+main > limit {
+			lines = append(lines, l)
+			l = &line{}
+		}
+		l.add(c, horizontal, gap)
+	}
+	lines = append(lines, l)
+	n.breaks = n.breaks[:0]
+	for _, l := range lines {
+		n.breaks = append(n.breaks, len(l.children))
+	}
+	return lines
+}
+
+// the lines the flow children were split into when the box was sized, measured at the current size of the children
+func (n *Box) wrapped() []*line {
+	horizontal := n.Tag == "row"
+	gap, _ := n.gaps(horizontal)
+	flow := n.flow()
+	var lines []*line
+	i := 0
+	for _, k := range n.breaks {
+		l := &line{}
+		for _, c := range flow[i:min(i+k, len(flow))] {
+			l.add(c, horizontal, gap)
+		}
+		lines = append(lines, l)
+		i += k
+	}
+	if len(lines) == 0 || i != len(flow) {
+		return n.lines(0)
+	}
+	return lines
+}
+
+// add the child to the end of the line, after a gap if the line isn't empty
+// the main extent of each line includes the gaps between its children
+func (l *line) add(c *Box, horizontal bool, gap int) {
+	main, cross := c.extents(horizontal)
+	if len(l.children) > 0 {
+		l.main += gap
+	}
+	l.children = append(l.children, c)
+	l.main += main
+	l.cross = max(l.cross, cross)
+}
+
+// the gap between children along the main and cross axes
+func (n *Box) gaps(horizontal bool) (int, int) {
+	if horizontal {
+		return n.Style.HGap, n.Style.VGap
+	}
+	return n.Style.VGap, n.Style.HGap
+}
+
+// the outer size of the box along the main and cross axes
+func (n *Box) extents(horizontal bool) (int, int) {
+	if horizontal {
+		return n.OuterWidth, n.OuterHeight
+	}
+	return n.OuterHeight, n.OuterWidth
+}
+
+func (n *Box) growFactor(horizontal bool) int {
+	if horizontal {
+		return n.Style.HGrow
+	}
+	return n.Style.VGrow
+}
+
+func (n *Box) fill(horizontal bool, size int) {
+	if horizontal {
+		n.fillWidth(size)
+	} else {
+		n.fillHeight(size)
+	}
+}
+
+// the size of the image the root of the tree is drawn on, or 0 if unknown
+func (n *Box) viewport() (int, int) {
+	if root := n.root(); root.target != nil {
+		bounds := root.target.Bounds()
+		return bounds.Dx(), bounds.Dy()
+	}
+	return 0, 0
+}
+
+// the space the parent has available for its content, which is the viewport for the root
+func (n *Box) container() (int, int) {
+	if n.Parent != nil {
+		return n.Parent.availWidth, n.Parent.availHeight
+	}
+	return n.viewport()
+}
+
+// the box percentages are relative to, which is the content box of the parent, or the viewport for the root
+// the content box of the parent is only known once the parent has grown, until then percentages are 0
+func (n *Box) basis() (int, int) {
+	if n.Parent != nil {
+		return n.Parent.ContentWidth, n.Parent.ContentHeight
+	}
+	return n.viewport()
+}
+
+// resolve percentages of the parent's content box, and vw and vh units of the viewport, to pixels
+func (n *Box) resolveLengths() {
+	if len(n.Style.relative) == 0 {
+		return
+	}
+	w, h := n.basis()
+	vw, vh := n.viewport()
+	for f, l := range n.Style.relative {
+		field, horizontal := n.Style.lengthField(f)
+		base := h
+		if horizontal {
+			base = w
+		}
+		*field = l.pixels(n.Style.Font, n.Style.scale(), base, vw, vh)
+	}
+}
+
+// the space available for the content of the box, or 0 if unbounded
+func (n *Box) available() (int, int) {
+	w, h := n.container()
+	m, p := n.Style.Margin, n.Style.Padding
+	if w > 0 {
+		w = max(0, w-m.Left-m.Right-p.Left-p.Right)
+	}
+	if h > 0 {
+		h = max(0, h-m.Top-m.Bottom-p.Top-p.Bottom)
+	}
+	if n.Style.MaxWidth > 0 && (w == 0 || n.Style.MaxWidth < w) {
+		w = n.Style.MaxWidth
+	}
+	if n.Style.MaxHeight > 0 && (h == 0 || n.Style.MaxHeight < h) {
+		h = n.Style.MaxHeight
+	}
+	if n.Style.Overflow == OverflowScroll {
+		horizontal, vertical := n.scrollAxes()
+		if horizontal {
+			w = 0
+		}
+		if vertical {
+			h = 0
+		}
+	}
+	return w, h
+}
+
 func (n *Box) fillWidth(w int) {
 	if w < n.OuterWidth {
 		return
 	}
 	mr, ml := n.Style.Margin.Right, n.Style.Margin.Left
 	pr, pl := n.Style.Padding.Right, n.Style.Padding.Left
-	n.OuterWidth = w
-	n.InnerWidth = n.OuterWidth - ml - mr
-	n.ContentWidth = n.InnerWidth - pr - pl
+	n.ContentWidth = w - ml - mr - pr - pl
+	if n.Style.MaxWidth > 0 && n.ContentWidth > n.Style.MaxWidth {
+		n.ContentWidth = n.Style.MaxWidth
+	}
+	n.InnerWidth = n.ContentWidth + pr + pl
+	n.OuterWidth = n.InnerWidth + ml + mr
 }
 
 func (n *Box) fillHeight(h int) {
@@ -186,46 +641,27 @@ func (n *Box) fillHeight(h int) {
 	}
 	mt, mb := n.Style.Margin.Top, n.Style.Margin.Bottom
 	pt, pb := n.Style.Padding.Top, n.Style.Padding.Bottom
-	n.OuterHeight = h
-	n.InnerHeight = n.OuterHeight - mt - mb
-	n.ContentHeight = n.InnerHeight - pt - pb
-}
-
-// returns the horizontal and vertical space leftover in the box
-func (n *Box) space() (int, int) {
-	maxChildWidth := 0
-	maxChildHeight := 0
-	totalChildWidths := 0
-	totalChildHeights := 0
-	for _, c := range n.Children {
-		if !c.Style.Display || c.Style.Float {
-			continue
-		}
-		maxChildWidth = max(maxChildWidth, c.OuterWidth)
-		maxChildHeight = max(maxChildHeight, c.OuterHeight)
-		totalChildWidths += c.OuterWidth
-		totalChildHeights += c.OuterHeight
-	}
-
-	var hspace, vspace int
-	if n.Tag == "row" {
-		hspace = n.InnerWidth - totalChildWidths
-		vspace = n.InnerHeight - maxChildHeight
-	} else {
-		hspace = n.InnerWidth - maxChildWidth
-		vspace = n.InnerHeight - totalChildHeights
+	n.ContentHeight = h - mt - mb - pt - pb
+	if n.Style.MaxHeight > 0 && n.ContentHeight > n.Style.MaxHeight {
+		n.ContentHeight = n.Style.MaxHeight
 	}
-	return hspace, vspace
+	n.InnerHeight = n.ContentHeight + pt + pb
+	n.OuterHeight = n.InnerHeight + mt + mb
 }
 
 // place the children in the box according to their justification
+// the children of a restored subtree keep their places, moved along with the box
 func (n *Box) justify() {
-	r := n.innerRect()
-	hspace, vspace := n.space()
-	for _, c := range n.Children {
-		if !c.Style.Display || c.Style.Float {
-			continue
+	// reused boxes keep the positions they had when rebuilt, scrolled by their current scroll offset, and are moved
+	// with the box, anchors are placed again after justify and lists are never reused
+	if n.cache.reused {
+		for _, c := range n.Children {
+			c.translate(n.X-n.cache.final.X, n.Y-n.cache.final.Y)
 		}
+		return
+	}
+	r := n.innerRect()
+	for _, c := range n.flow() {
 		c.X = r.Min.X
 		c.Y = r.Min.Y
 		var ox, oy int
@@ -239,106 +675,182 @@ func (n *Box) justify() {
 		c.X += ox
 		c.Y += oy
 	}
-	extents := make([][2]int, len(n.Children))
-	for i, c := range n.Children {
-		if !c.Style.Display || c.Style.Float {
-			continue
-		}
-		if n.Tag == "row" {
-			extents[i][0] = c.OuterWidth
-			extents[i][1] = c.OuterHeight
-		} else {
-			extents[i][0] = c.OuterHeight
-			extents[i][1] = c.OuterWidth
+	if n.Tag == "grid" {
+		n.justifyGrid()
+	} else if n.Tag == "list" {
+		n.justifyList()
+	} else if n.Tag == "stack" {
+		// the children of a stack overlap, each aligned within the content box of the stack
+		p := n.Style.Padding
+		for _, c := range n.flow() {
+			c.X += p.Left + align(c.Style.HJustSelf, n.ContentWidth, c.OuterWidth)
+			c.Y += p.Top + align(c.Style.VJustSelf, n.ContentHeight, c.OuterHeight)
 		}
-	}
-	var offsets [][2]int
-	if n.Tag == "row" {
-		offsets = distribute(hspace, n.InnerHeight, n.Style.HJust, n.Style.VJust, extents)
 	} else {
-		offsets = distribute(vspace, n.InnerWidth, n.Style.VJust, n.Style.HJust, extents)
+		n.justifyLines()
 	}
-	for i, c := range n.Children {
-		if c.Style.Float {
-			switch c.Style.HJustSelf {
-			case Start:
-				c.X = r.Min.X
-			case End:
-				c.X = r.Min.X + n.InnerWidth - c.OuterWidth
-			case Center:
-				c.X = r.Min.X + (n.InnerWidth / 2) - (c.OuterWidth / 2)
-			}
-			switch c.Style.VJustSelf {
-			case Start:
-				c.Y = r.Min.Y
-			case End:
-				c.Y = r.Min.Y + n.InnerHeight - c.OuterHeight
-			case Center:
-				c.Y = r.Min.Y + (n.InnerHeight / 2) - (c.OuterHeight / 2)
-			}
+	for _, c := range n.Children {
+		if !c.Style.Float {
 			continue
 		}
-		if n.Tag == "row" {
-			c.X += offsets[i][0]
-			c.Y += offsets[i][1]
-		} else {
-			c.Y += offsets[i][0]
-			c.X += offsets[i][1]
+		switch c.Style.HJustSelf {
+		case Start, Stretch:
+			c.X = r.Min.X
+		case End:
+			c.X = r.Min.X + n.InnerWidth - c.OuterWidth
+		case Center:
+			c.X = r.Min.X + (n.InnerWidth / 2) - (c.OuterWidth / 2)
+		}
+		switch c.Style.VJustSelf {
+		case Start, Stretch:
+			c.Y = r.Min.Y
+		case End:
+			c.Y = r.Min.Y + n.InnerHeight - c.OuterHeight
+		case Center:
+			c.Y = r.Min.Y + (n.InnerHeight / 2) - (c.OuterHeight / 2)
 		}
 	}
+	if n.Style.Overflow == OverflowScroll {
+		n.scrollChildren()
+	}
 	for _, c := range n.Children {
 		c.justify()
 	}
 }
 
-func distribute(mainspace, crossspace int, mainj, crossj Justification, extents [][2]int) [][2]int {
+// place the children of a row or col along their lines
+func (n *Box) justifyLines() {
+	horizontal := n.Tag == "row"
+	contentMain, contentCross := n.ContentHeight, n.ContentWidth
+	mainj, crossj := n.Style.VJust, n.Style.HJust
+	if horizontal {
+		contentMain, contentCross = n.ContentWidth, n.ContentHeight
+		mainj, crossj = n.Style.HJust, n.Style.VJust
+	}
+	mainGap, crossGap := n.gaps(horizontal)
+	lines := n.wrapped()
+	// position the lines along the cross axis, a single line spans the whole content box
+	lineOffsets := make([][2]int, len(lines))
+	if len(lines) > 1 {
+		crossspace := contentCross - totalGap(len(lines), crossGap)
+		extents := make([][2]int, len(lines))
+		for i, l := range lines {
+			crossspace -= l.cross
+			extents[i][0] = l.cross
+		}
+		lineOffsets = distribute(crossspace, 0, crossGap, n.Style.AlignContent, Start, extents)
+	}
+	for i, l := range lines {
+		cross := l.cross
+		if len(lines) == 1 {
+			cross = contentCross
+		}
+		extents := make([][2]int, len(l.children))
+		for j, c := range l.children {
+			extents[j][0], extents[j][1] = c.extents(horizontal)
+		}
+		offsets := distribute(contentMain-l.main, cross, mainGap, mainj, crossj, extents)
+		// reversed boxes mirror their children along the main axis, so that start justification is at the end
+		if n.Style.Reverse {
+			for j := range offsets {
+				offsets[j][0] = contentMain - offsets[j][0] - extents[j][0]
+			}
+		}
+		if horizontal && crossj == Baseline {
+			n.alignBaselines(l.children, offsets)
+		}
+		// the lines fill the content box, inside the padding
+		for j, c := range l.children {
+			c.X += n.Style.Padding.Left
+			c.Y += n.Style.Padding.Top
+			if horizontal {
+				c.X += offsets[j][0]
+				c.Y += lineOffsets[i][0] + offsets[j][1]
+			} else {
+				c.Y += offsets[j][0]
+				c.X += lineOffsets[i][0] + offsets[j][1]
+			}
+		}
+	}
+}
+
+// offset the children of a line along the cross axis so that their baselines match the lowest baseline
+func (n *Box) alignBaselines(children []*Box, offsets [][2]int) {
+	lowest := 0
+	for _, c := range children {
+		lowest = max(lowest, c.baseline())
+	}
+	for j, c := range children {
+		offsets[j][1] = lowest - c.baseline()
+	}
+}
+
+// the distance from the top of the box to the baseline of its first line of text
+// boxes without text use the bottom of their content, and containers use the baseline of their first child
+func (n *Box) baseline() int {
+	m, p := n.Style.Margin, n.Style.Padding
+	switch n.Tag {
+	case "text", "button", "input":
+		return m.Top + p.Top + text.Baseline(n.Style.Font, n.ContentHeight, text.Center)
+	case "p", "textarea":
+		return m.Top + p.Top + text.Baseline(n.Style.Font, n.ContentHeight, text.Start)
+	}
+	if flow := n.flow(); len(flow) > 0 && n.Tag != "canvas" {
+		return m.Top + flow[0].baseline()
+	}
+	return m.Top + p.Top + n.ContentHeight
+}
+
+// offsets of items with the given extents along the main and cross axes, with gap between each pair of adjacent
+// items along the main axis
+func distribute(mainspace, crossspace, gap int, mainj, crossj Justification, extents [][2]int) [][2]int {
 	offsets := make([][2]int, len(extents))
 	for i := range extents {
 		switch mainj {
-		case Start:
+		case Start, Stretch, Baseline:
 			if i == 0 {
 				offsets[i][0] = 0
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0]
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap
 			}
 		case End:
 			if i == 0 {
 				offsets[i][0] = mainspace
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0]
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap
 			}
 		case Center:
 			if i == 0 {
 				offsets[i][0] = mainspace / 2
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0]
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap
 			}
 		case Evenly:
 			spacing := int(math.Floor(float64(mainspace) / float64(len(extents)+1)))
 			if i == 0 {
 				offsets[i][0] = spacing
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + spacing
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap + spacing
 			}
 		case Around:
 			spacing := int(math.Floor(float64(mainspace) / float64(len(extents))))
 			if i == 0 {
 				offsets[i][0] = int(math.Floor(float64(spacing) / 2))
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + spacing
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap + spacing
 			}
 		case Between:
 			spacing := int(math.Floor(float64(mainspace) / float64(len(extents)-1)))
 			if i == 0 {
 				offsets[i][0] = 0
 			} else {
-				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + spacing
+				offsets[i][0] = offsets[i-1][0] + extents[i-1][0] + gap + spacing
 			}
 		default:
 			panic(fmt.Errorf("can't handle main axis justification %q", mainj))
 		}
 		switch crossj {
-		case Start:
+		case Start, Stretch, Baseline:
 			offsets[i][1] = 0
 		case End:
 			offsets[i][1] = crossspace - extents[i][1]
@@ -1,7 +1,11 @@
 package bento
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func TestJustificationExtraSpace(t *testing.T) {
@@ -9,6 +13,8 @@ func TestJustificationExtraSpace(t *testing.T) {
 	n.Style.parseAttributes()
 	n.layout.InnerWidth = 20
 	n.layout.InnerHeight = 20
+	n.layout.ContentWidth = 20
+	n.layout.ContentHeight = 20
 	bounds := [][2]int{
 		{2, 1},
 		{3, 2},
@@ -116,6 +122,8 @@ func TestJustificationNoExtraSpace(t *testing.T) {
 	n.Style.parseAttributes()
 	n.layout.InnerWidth = 14
 	n.layout.InnerHeight = 4
+	n.layout.ContentWidth = 14
+	n.layout.ContentHeight = 4
 	bounds := [][2]int{
 		{2, 1},
 		{3, 2},
@@ -184,3 +192,716 @@ func TestJustificationNoExtraSpace(t *testing.T) {
 		}
 	}
 }
+
+func init() {
+	// tests run without a display to read the device scale factor from
+	deviceScaleFactor = func() float64 { return 1 }
+}
+
+type LayoutComponent struct {
+	Markup string
+}
+
+func (c *LayoutComponent) UI() string {
+	return c.Markup
+}
+
+// build the markup and lay it out on a target of the given size
+func layoutTree(t *testing.T, markup string, width, height int) *Box {
+	t.Helper()
+	box, err := Build(&LayoutComponent{Markup: markup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(width, height)
+	box.relayout()
+	return box
+}
+
+func checkPositions(t *testing.T, n *Box, want [][2]int) {
+	t.Helper()
+	if len(n.Children) != len(want) {
+		t.Fatalf("got %d children, want %d", len(n.Children), len(want))
+	}
+	for i, c := range n.Children {
+		if c.X != want[i][0] || c.Y != want[i][1] {
+			t.Fatalf("child %d got (%d,%d), want (%d,%d)", i, c.X, c.Y, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		attrs string
+		want  [][2]int
+	}{
+		{
+			attrs: ``,
+			want:  [][2]int{{0, 0}, {40, 0}, {0, 10}, {40, 10}, {0, 20}},
+		},
+		{
+			attrs: `grow="1" justify="center start"`,
+			want:  [][2]int{{10, 0}, {50, 0}, {10, 10}, {50, 10}, {30, 20}},
+		},
+		{
+			attrs: `grow="1" justify="end start" alignContent="end"`,
+			want:  [][2]int{{20, 20}, {60, 20}, {20, 30}, {60, 30}, {60, 40}},
+		},
+		{
+			attrs: `grow="1" alignContent="between"`,
+			want:  [][2]int{{0, 0}, {40, 0}, {0, 20}, {40, 20}, {0, 40}},
+		},
+	}
+	for _, test := range tests {
+		box := layoutTree(t, `<row wrap="true" `+test.attrs+`>
+			<canvas minWidth="40px" minHeight="10px" />
+			<canvas minWidth="40px" minHeight="10px" />
+			<canvas minWidth="40px" minHeight="10px" />
+			<canvas minWidth="40px" minHeight="10px" />
+			<canvas minWidth="40px" minHeight="10px" />
+		</row>`, 100, 50)
+		checkPositions(t, box, test.want)
+	}
+
+	box := layoutTree(t, `<col wrap="true">
+		<canvas minWidth="10px" minHeight="20px" />
+		<canvas minWidth="10px" minHeight="20px" />
+		<canvas minWidth="10px" minHeight="20px" />
+	</col>`, 100, 50)
+	checkPositions(t, box, [][2]int{{0, 0}, {0, 20}, {10, 0}})
+	if box.OuterWidth != 20 || box.OuterHeight != 40 {
+		t.Fatalf("got %dx%d, want 20x40", box.OuterWidth, box.OuterHeight)
+	}
+}
+
+func TestWrapGrowPadding(t *testing.T) {
+	box := layoutTree(t, `<row wrap="true" padding="20px" minWidth="200px" maxWidth="200px">
+		<canvas minWidth="50px" minHeight="10px" grow="1" />
+		<canvas minWidth="50px" minHeight="10px" grow="1" />
+		<canvas minWidth="50px" minHeight="10px" grow="1" />
+		<canvas minWidth="50px" minHeight="10px" grow="1" />
+		<canvas minWidth="50px" minHeight="10px" grow="1" />
+	</row>`, 800, 600)
+	checkPositions(t, box, [][2]int{{20, 20}, {70, 20}, {120, 20}, {170, 20}, {20, 30}})
+	for i, c := range box.Children {
+		want := 50
+		if i == 4 {
+			want = 200
+		}
+		if c.OuterWidth != want {
+			t.Errorf("child %d got width %d, want %d", i, c.OuterWidth, want)
+		}
+	}
+	if box.ContentWidth != 200 || box.ContentHeight != 20 {
+		t.Errorf("got content %dx%d, want 200x20", box.ContentWidth, box.ContentHeight)
+	}
+}
+
+func TestGap(t *testing.T) {
+	tests := []struct {
+		markup string
+		want   [][2]int
+	}{
+		{
+			markup: `<row gap="5px">
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+			</row>`,
+			want: [][2]int{{0, 0}, {15, 0}, {30, 0}},
+		},
+		{
+			markup: `<row gap="5px" grow="1 0" justify="between">
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+			</row>`,
+			want: [][2]int{{0, 0}, {45, 0}, {90, 0}},
+		},
+		{
+			markup: `<row gap="4px 6px" wrap="true">
+				<canvas minWidth="40px" minHeight="10px" />
+				<canvas minWidth="40px" minHeight="10px" />
+				<canvas minWidth="40px" minHeight="10px" />
+			</row>`,
+			want: [][2]int{{0, 0}, {44, 0}, {0, 16}},
+		},
+		{
+			markup: `<col gap="5px" grow="0 1" justify="start end">
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+			</col>`,
+			want: [][2]int{{0, 75}, {0, 90}},
+		},
+		{
+			markup: `<grid columns="10px 10px" gap="2px 3px">
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+				<canvas minWidth="10px" minHeight="10px" />
+			</grid>`,
+			want: [][2]int{{0, 0}, {12, 0}, {0, 13}},
+		},
+	}
+	for _, test := range tests {
+		box := layoutTree(t, test.markup, 100, 100)
+		checkPositions(t, box, test.want)
+	}
+	box := layoutTree(t, `<col gap="5px">
+		<canvas minWidth="10px" minHeight="10px" />
+		<canvas minWidth="10px" minHeight="10px" />
+	</col>`, 100, 100)
+	if box.OuterHeight != 25 {
+		t.Fatalf("got height %d, want 25", box.OuterHeight)
+	}
+}
+
+func TestRelativeLengths(t *testing.T) {
+	box := layoutTree(t, `<col padding="10%" grow="1">
+		<canvas minWidth="50%" minHeight="10vh" margin="2.5px 5%" />
+		<canvas minWidth="25vw" minHeight="1.5lh" offset="10% -1" />
+	</col>`, 200, 100)
+	if p := box.Style.Padding; p != (Spacing{10, 20, 10, 20}) {
+		t.Fatalf("got padding %v, want 10%% of the viewport", p)
+	}
+	a, b := box.Children[0], box.Children[1]
+	// the parent's content box is 200x100 less its padding
+	if a.ContentWidth != 80 || a.ContentHeight != 10 {
+		t.Fatalf("got %dx%d, want 80x10", a.ContentWidth, a.ContentHeight)
+	}
+	if m := a.Style.Margin; m != (Spacing{4, 3, 4, 3}) {
+		t.Fatalf("got margin %v, want {4 3 4 3}", m)
+	}
+	lh := b.Style.Font.Metrics().Height.Round()
+	if want := int(1.5*float64(lh) + 0.5); b.ContentWidth != 50 || b.ContentHeight != want {
+		t.Fatalf("got %dx%d, want 50x%d", b.ContentWidth, b.ContentHeight, want)
+	}
+	if b.Style.OffsetX != 16 || b.Style.OffsetY != -1 {
+		t.Fatalf("got offset (%d,%d), want (16,-1)", b.Style.OffsetX, b.Style.OffsetY)
+	}
+	if _, err := parseSize("10%", nil, 1); err == nil {
+		t.Fatal("expected an error for a relative size")
+	}
+	if l, err := parseLength(".5em"); err != nil || l != (Length{0.5, "em"}) {
+		t.Fatalf("got %v, %v, want 0.5em", l, err)
+	}
+	if _, err := parseLength("12pxjunk"); err == nil {
+		t.Fatal("expected an error for a length with trailing characters")
+	}
+
+	// percentages are of the parent's content box, not of the space offered to the parent
+	box = layoutTree(t, `<row><col minWidth="100px"><canvas minWidth="50%" minHeight="10px" /></col></row>`, 800, 100)
+	if w := box.Children[0].Children[0].OuterWidth; w != 50 {
+		t.Fatalf("got width %d, want 50%% of the 100px parent", w)
+	}
+}
+
+func TestMaxSizeOverflow(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<row maxWidth="50px" maxHeight="20px" overflow="hidden">
+			<canvas minWidth="40px" minHeight="30px" />
+			<canvas minWidth="40px" minHeight="30px" />
+		</row>
+		<canvas maxWidth="10px" grow="1" />
+	</col>`, 100, 100)
+	row, canvas := box.Children[0], box.Children[1]
+	if row.OuterWidth != 50 || row.OuterHeight != 20 {
+		t.Fatalf("got %dx%d, want 50x20", row.OuterWidth, row.OuterHeight)
+	}
+	if canvas.OuterWidth != 10 {
+		t.Fatalf("got width %d, want growth limited to 10", canvas.OuterWidth)
+	}
+	if c := row.Children[1]; c.X != 40 || c.clipped(45, 5) || !c.clipped(55, 5) || !c.clipped(45, 25) {
+		t.Fatal("overflowing child should only receive events inside its parent")
+	}
+	if _, err := Build(&LayoutComponent{Markup: `<col overflow="auto" />`}); err == nil {
+		t.Fatal("expected an error for an invalid overflow")
+	}
+}
+
+func TestScrollViewport(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<col maxHeight="30px" overflow="scroll">
+			<canvas minWidth="40px" minHeight="20px" />
+			<canvas minWidth="40px" minHeight="20px" />
+			<canvas minWidth="40px" minHeight="20px" />
+		</col>
+	</col>`, 100, 100)
+	view := box.Children[0]
+	if view.OuterHeight != 30 || view.scrollHeight != 60 {
+		t.Fatalf("got height %d scrolling %d, want 30 scrolling 60", view.OuterHeight, view.scrollHeight)
+	}
+	view.scrollTo(0, 15)
+	checkPositions(t, view, [][2]int{{0, -15}, {0, 5}, {0, 25}})
+	view.scrollTo(0, 100)
+	checkPositions(t, view, [][2]int{{0, -30}, {0, -10}, {0, 10}})
+	if c := view.Children[0]; !c.clipped(5, -15) || c.clipped(5, 5) {
+		t.Fatal("children should only receive events inside the viewport")
+	}
+	box.relayout()
+	checkPositions(t, view, [][2]int{{0, -30}, {0, -10}, {0, 10}})
+}
+
+func TestAspect(t *testing.T) {
+	tests := []struct {
+		markup        string
+		width, height int
+	}{
+		{`<col><canvas aspect="16/9" minWidth="160px" /></col>`, 160, 90},
+		{`<col><canvas aspect="0.5" minWidth="10px" minHeight="40px" /></col>`, 20, 40},
+		{`<row grow="1"><canvas aspect="2" grow="1 0" /></row>`, 300, 150},
+		{`<row grow="1"><canvas aspect="2/1" grow="1" /></row>`, 200, 100},
+		{`<col grow="1"><canvas aspect="1" grow="1" maxWidth="50px" /></col>`, 50, 50},
+	}
+	for _, test := range tests {
+		box := layoutTree(t, test.markup, 300, 100)
+		c := box.Children[0]
+		if c.OuterWidth != test.width || c.OuterHeight != test.height {
+			t.Errorf("%s: got %dx%d, want %dx%d", test.markup, c.OuterWidth, c.OuterHeight, test.width, test.height)
+		}
+	}
+	// the side derived from the aspect ratio grows the ancestors, and moves the siblings after it
+	markup := `<col grow="1">
+		<row grow="1 0"><canvas aspect="2" grow="1 0" /></row>
+		<canvas minWidth="10px" minHeight="10px" />
+		<row><col minHeight="40px"><canvas aspect="1/2" grow="0 1" /></col><canvas minWidth="10px" minHeight="10px" /></row>
+	</col>`
+	box := layoutTree(t, markup, 300, 300)
+	checkPositions(t, box, [][2]int{{0, 0}, {0, 150}, {0, 160}})
+	if row := box.Children[0]; row.OuterWidth != 300 || row.OuterHeight != 150 {
+		t.Errorf("got row %dx%d, want 300x150 to fit its canvas", row.OuterWidth, row.OuterHeight)
+	}
+	checkPositions(t, box.Children[2], [][2]int{{0, 160}, {20, 160}})
+	box.resize(ebiten.NewImage(200, 300))
+	checkSameLayout(t, box, layoutTree(t, markup, 200, 300))
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	checkSameLayout(t, box, layoutTree(t, markup, 200, 300))
+	if _, err := Build(&LayoutComponent{Markup: `<canvas aspect="16/0" />`}); err == nil {
+		t.Fatal("expected an error for an invalid aspect")
+	}
+}
+
+func TestStretch(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<row justify="start stretch" minHeight="40px">
+			<canvas minWidth="20px" minHeight="10px" />
+			<canvas minWidth="20px" minHeight="10px" />
+		</row>
+		<col minWidth="100px">
+			<canvas minWidth="20px" minHeight="10px" />
+			<canvas minWidth="20px" minHeight="10px" justifySelf="stretch start" />
+		</col>
+	</col>`, 200, 200)
+	row, col := box.Children[0], box.Children[1]
+	for i, c := range row.Children {
+		if c.OuterHeight != 40 {
+			t.Errorf("row child %d got height %d, want 40", i, c.OuterHeight)
+		}
+	}
+	if w0, w1 := col.Children[0].OuterWidth, col.Children[1].OuterWidth; w0 != 20 || w1 != 100 {
+		t.Errorf("col children got widths %d and %d, want 20 and 100", w0, w1)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<row maxWidth="100px">
+			<canvas minWidth="60px" minHeight="10px" />
+			<canvas minWidth="60px" minHeight="10px" />
+		</row>
+		<row maxWidth="100px">
+			<canvas minWidth="60px" minHeight="10px" />
+			<col shrink="1"><canvas minWidth="60px" minHeight="10px" /></col>
+		</row>
+		<row maxWidth="100px">
+			<col shrink="1"><canvas minWidth="60px" minHeight="10px" /></col>
+			<col shrink="3"><canvas minWidth="60px" minHeight="10px" /></col>
+			<canvas minWidth="40px" minHeight="10px" />
+		</row>
+		<row maxWidth="100px">
+			<col shrink="1" minWidth="50px"><canvas minWidth="60px" minHeight="10px" /></col>
+			<col shrink="1"><canvas minWidth="60px" minHeight="10px" /></col>
+			<canvas minWidth="40px" minHeight="10px" />
+		</row>
+		<row maxWidth="100px">
+			<text shrink="1">a long line of text that won't fit</text>
+		</row>
+		<row maxWidth="100px" padding="20px">
+			<col shrink="1"><canvas minWidth="60px" minHeight="10px" /></col>
+			<col shrink="1"><canvas minWidth="60px" minHeight="10px" /></col>
+		</row>
+	</col>`, 200, 200)
+	tests := [][]int{{60, 60}, {60, 40}, {45, 15, 40}, {50, 10, 40}, {100}, {50, 50}}
+	for i, want := range tests {
+		var got []int
+		for _, c := range box.Children[i].Children {
+			got = append(got, c.OuterWidth)
+		}
+		if !equal(got, want) {
+			t.Errorf("row %d got widths %v, want %v", i, got, want)
+		}
+	}
+	if padded := box.Children[5]; padded.ContentWidth != 100 {
+		t.Errorf("got padded content width %d, want 100", padded.ContentWidth)
+	}
+	if label := box.Children[4].Children[0].label; !strings.HasSuffix(label, "…") || len(label) >= len("a long line of text that won't fit") {
+		t.Errorf("got label %q, want the text ellipsized to fit", label)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		attrs string
+		want  [][2]int
+	}{
+		{``, [][2]int{{50, 0}, {0, 0}, {20, 0}}},
+		{`direction="reverse"`, [][2]int{{40, 0}, {80, 0}, {50, 0}}},
+		{`direction="reverse" justify="end start"`, [][2]int{{0, 0}, {40, 0}, {10, 0}}},
+	}
+	for _, test := range tests {
+		box := layoutTree(t, `<col><row minWidth="100px" `+test.attrs+`>
+			<canvas minWidth="10px" minHeight="10px" order="1" />
+			<canvas minWidth="20px" minHeight="10px" />
+			<canvas minWidth="30px" minHeight="10px" />
+		</row></col>`, 200, 200)
+		row := box.Children[0]
+		checkPositions(t, row, test.want)
+		for i, c := range row.Children {
+			if c.Style.ZIndex != i+1 {
+				t.Fatalf("child %d got zIndex %d, want draw order unchanged", i, c.Style.ZIndex)
+			}
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<row justify="start baseline">
+			<text font="NotoSans 24">Large</text>
+			<text font="NotoSans 14" margin="4px">small</text>
+			<col><text font="NotoSans 18">nested</text></col>
+			<canvas minWidth="10px" minHeight="8px" />
+		</row>
+	</col>`, 400, 200)
+	row := box.Children[0]
+	want := row.Children[0].Y + row.Children[0].baseline()
+	for i, c := range row.Children {
+		if got := c.Y + c.baseline(); got != want {
+			t.Errorf("child %d got baseline %d, want %d", i, got, want)
+		}
+	}
+	if c := row.Children[3]; c.Y+c.OuterHeight != want {
+		t.Errorf("got canvas bottom %d, want it on the baseline %d", c.Y+c.OuterHeight, want)
+	}
+}
+
+func TestStack(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<stack>
+			<canvas minWidth="60px" minHeight="40px" />
+			<canvas minWidth="10px" minHeight="10px" justifySelf="end start" zIndex="10" />
+			<canvas minWidth="10px" minHeight="10px" justifySelf="center center" />
+			<canvas minWidth="10px" minHeight="10px" offset="4 -2" />
+			<canvas minHeight="4px" justifySelf="stretch end" />
+		</stack>
+	</col>`, 200, 200)
+	stack := box.Children[0]
+	if stack.OuterWidth != 60 || stack.OuterHeight != 40 {
+		t.Fatalf("got %dx%d, want the stack to fit its largest child 60x40", stack.OuterWidth, stack.OuterHeight)
+	}
+	checkPositions(t, stack, [][2]int{{0, 0}, {25, 15}, {4, 29}, {0, 36}, {50, 0}})
+	if w := stack.Children[3].OuterWidth; w != 60 {
+		t.Fatalf("got width %d, want stretched child to fill the stack", w)
+	}
+
+	stack = layoutTree(t, `<stack padding="10px">
+		<canvas minWidth="30px" minHeight="30px" />
+		<canvas minWidth="10px" minHeight="10px" justifySelf="end end" />
+		<canvas minHeight="4px" justifySelf="stretch center" />
+	</stack>`, 200, 200)
+	checkPositions(t, stack, [][2]int{{10, 10}, {30, 30}, {10, 23}})
+	if w := stack.Children[2].OuterWidth; w != 30 {
+		t.Fatalf("got width %d, want stretched child to fill the content of the stack", w)
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	tests := []struct {
+		target, anchor string
+		want           [2]int
+	}{
+		{`offset="20 20"`, `bottom-left`, [2]int{20, 40}},
+		{`offset="20 20"`, `right`, [2]int{40, 25}},
+		{`offset="20 20"`, `top-right`, [2]int{30, 10}},
+		{`offset="20 20"`, `left-bottom`, [2]int{10, 30}},
+		// flipped to stay on screen
+		{`offset="20 85"`, `bottom`, [2]int{25, 75}},
+		{`offset="85 20"`, `right-top`, [2]int{75, 20}},
+		// moved along the side to stay on screen
+		{`offset="95 20"`, `bottom-left`, [2]int{90, 40}},
+	}
+	for _, test := range tests {
+		box := layoutTree(t, `<col>
+			<canvas id="target" minWidth="20px" minHeight="20px" `+test.target+` />
+			<col><canvas minWidth="10px" minHeight="10px" anchor="#target `+test.anchor+`" /></col>
+		</col>`, 100, 100)
+		c := box.Children[1].Children[0]
+		if c.X != test.want[0] || c.Y != test.want[1] {
+			t.Errorf("%s: got (%d,%d), want (%d,%d)", test.anchor, c.X, c.Y, test.want[0], test.want[1])
+		}
+	}
+	if _, err := Build(&LayoutComponent{Markup: `<col><canvas anchor="#missing bottom" /></col>`}); err == nil {
+		t.Fatal("expected an error for a missing anchor target")
+	}
+	if _, err := Build(&LayoutComponent{Markup: `<col><canvas id="a" /><canvas anchor="#a bottom-top" /></col>`}); err == nil {
+		t.Fatal("expected an error for an invalid anchor alignment")
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	box, err := Build(&LayoutComponent{Markup: `<col>
+		<canvas minWidth="10px" minHeight="10px" padding="1px" md:padding="2px" lg:padding="4px" />
+		<canvas minWidth="10px" minHeight="10px" display="false" lg:display="true" />
+	</col>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		width, padding int
+		display        bool
+	}{
+		{320, 1, false},
+		{800, 2, false},
+		{1200, 4, true},
+		{320, 1, false},
+	}
+	for _, test := range tests {
+		box.target = ebiten.NewImage(test.width, 100)
+		box.relayout()
+		if p := box.Children[0].Style.Padding.Left; p != test.padding {
+			t.Errorf("width %d: got padding %d, want %d", test.width, p, test.padding)
+		}
+		if d := box.Children[1].Style.Display; d != test.display {
+			t.Errorf("width %d: got display %t, want %t", test.width, d, test.display)
+		}
+	}
+	if _, err := Build(&LayoutComponent{Markup: `<col xxl:padding="4px" />`}); err == nil {
+		t.Fatal("expected an error for an unknown breakpoint")
+	}
+	if _, err := Build(&LayoutComponent{Markup: `<col lg:grow="x" />`}); err == nil {
+		t.Fatal("expected an error for an invalid attribute at a breakpoint")
+	}
+}
+
+func TestScale(t *testing.T) {
+	box := layoutTree(t, `<col>
+		<canvas minWidth="10px" minHeight="1em" margin="2px" />
+		<text font="NotoSans 12">Scaled</text>
+	</col>`, 400, 400)
+	width, height := box.Children[0].OuterWidth, box.Children[1].OuterHeight
+	box.SetScale(2)
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	canvas, text := box.Children[0], box.Children[1]
+	if canvas.OuterWidth != 2*width {
+		t.Errorf("got width %d, want %d", canvas.OuterWidth, 2*width)
+	}
+	if d := text.OuterHeight - 2*height; d < -1 || d > 1 {
+		t.Errorf("got text height %d, want about %d", text.OuterHeight, 2*height)
+	}
+	if box.Scale() != 2 {
+		t.Errorf("got scale %f, want 2", box.Scale())
+	}
+
+	defer func(f func() float64) { deviceScaleFactor = f }(deviceScaleFactor)
+	deviceScaleFactor = func() float64 { return 2 }
+	box = layoutTree(t, `<col />`, 400, 400)
+	if box.Scale() != 1 {
+		t.Errorf("got scale %f, want 1 until the game draws at the resolution of the device", box.Scale())
+	}
+	if w, h := box.Layout(200, 100); w != 400 || h != 200 {
+		t.Errorf("got layout %dx%d, want 400x200 device pixels", w, h)
+	}
+	if box.Scale() != 2 || !box.dirty {
+		t.Errorf("got scale %f, dirty=%t, want the device scale and a rebuild", box.Scale(), box.dirty)
+	}
+}
+
+func cacheMarkup(title string) string {
+	return fmt.Sprintf(`<col grow="1" justify="center start">
+		<text font="NotoSans 16">%s</text>
+		<row gap="5px" grow="1 0" justify="between">
+			<p font="NotoSans 16" maxWidth="80px">A paragraph that wraps over several lines</p>
+			<canvas minWidth="10px" minHeight="10px" />
+		</row>
+		<col><text font="NotoSans 16">Below</text></col>
+	</col>`, title)
+}
+
+// check that every box in a has the same layout as the matching box in b
+func checkSameLayout(t *testing.T, a, b *Box) {
+	t.Helper()
+	if a.Tag != b.Tag || len(a.Children) != len(b.Children) {
+		t.Fatalf("got %s with %d children, want %s with %d", a.Tag, len(a.Children), b.Tag, len(b.Children))
+	}
+	if a.layout != b.layout {
+		t.Fatalf("%s got layout %+v, want %+v", a.Tag, a.layout, b.layout)
+	}
+	for i := range a.Children {
+		checkSameLayout(t, a.Children[i], b.Children[i])
+	}
+}
+
+func TestLayoutCache(t *testing.T) {
+	c := &LayoutComponent{Markup: cacheMarkup("Title")}
+	box := layoutTree(t, c.Markup, 400, 300)
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if !box.cache.reused {
+		t.Errorf("unchanged tree was laid out again")
+	}
+	checkSameLayout(t, box, layoutTree(t, c.Markup, 400, 300))
+
+	c.Markup = cacheMarkup("A much longer title")
+	box.Component = c
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if box.cache.reused || box.Children[0].cache.restored {
+		t.Errorf("changed text and its ancestors were not laid out again")
+	}
+	if !box.Children[1].cache.restored || !box.Children[2].cache.reused {
+		t.Errorf("unchanged siblings were laid out again")
+	}
+	checkSameLayout(t, box, layoutTree(t, c.Markup, 400, 300))
+
+	box.resize(ebiten.NewImage(200, 300))
+	checkSameLayout(t, box, layoutTree(t, c.Markup, 200, 300))
+}
+
+type cacheComponent struct {
+	Title string
+	Side  *Style
+}
+
+func (c *cacheComponent) UI() string {
+	return `<col grow="1">
+		<text id="title" font="NotoSans 16">{{.Title}}</text>
+		<row margin="10%"><canvas minWidth="10px" minHeight="10px" /></row>
+		<Side />
+		<col maxHeight="30px" overflow="scroll">
+			<canvas minWidth="40px" minHeight="20px" />
+			<canvas minWidth="40px" minHeight="20px" />
+			<col><canvas minWidth="10px" minHeight="10px" anchor="#title right" /></col>
+		</col>
+	</col>`
+}
+
+// lay out a new build of the component, scrolled as the cached tree is
+func freshLayout(t *testing.T, c *cacheComponent, scroll int) *Box {
+	t.Helper()
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(400, 300)
+	box.relayout()
+	box.Children[3].scrollTo(0, scroll)
+	box.relayout()
+	return box
+}
+
+func TestLayoutCacheInvalidation(t *testing.T) {
+	c := &cacheComponent{Title: "Title", Side: &Style{Extends: "canvas", MinWidth: 10, MinHeight: 10, Display: true}}
+	box := freshLayout(t, c, 15)
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	relative := box.Children[1]
+	if !relative.cache.restored {
+		t.Errorf("unchanged box with a relative margin was laid out again")
+	}
+	if relative.Style.Margin.Left != 40 || relative.Style.Margin.Top != 30 {
+		t.Errorf("restored box got margin %+v, want 40px across and 30px down", relative.Style.Margin)
+	}
+	checkSameLayout(t, box, freshLayout(t, c, 15))
+
+	// a style from a subcomponent that changes outside of its attributes
+	c.Side = &Style{Extends: "canvas", MinWidth: 30, MinHeight: 10, Display: true}
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if box.Children[2].cache.restored || box.Children[2].OuterWidth != 30 {
+		t.Errorf("changed subcomponent style was not laid out again")
+	}
+	checkSameLayout(t, box, freshLayout(t, c, 15))
+
+	// the scrolled box is reused as it was scrolled, and the box anchored inside it follows the changed title
+	c.Title = "A much longer title"
+	if err := box.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if !box.Children[3].cache.reused {
+		t.Errorf("unchanged scrolled box was laid out again")
+	}
+	checkSameLayout(t, box, freshLayout(t, c, 15))
+}
+
+func BenchmarkRelayout(b *testing.B) {
+	rows := func(edited string) string {
+		markup := new(strings.Builder)
+		markup.WriteString(`<col grow="1">`)
+		for i := 0; i < 500; i++ {
+			title := fmt.Sprintf("Item %d", i)
+			if i == 250 {
+				title = edited
+			}
+			fmt.Fprintf(markup, `<row gap="4px" justify="between">
+				<text font="NotoSans 16">%s</text>
+				<p font="NotoSans 16" maxWidth="200px">A paragraph that wraps over several lines of text</p>
+				<canvas minWidth="10px" minHeight="10px" />
+			</row>`, title)
+		}
+		markup.WriteString(`</col>`)
+		return markup.String()
+	}
+	c := &LayoutComponent{Markup: rows("Item 250")}
+	// one row's text alternates between the two, so every rebuild edits a single node
+	edits := [2]string{rows("Edited"), rows("Item 250")}
+	target := ebiten.NewImage(800, 600)
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.Markup = edits[i%2]
+			box, err := Build(c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			box.target = target
+			box.relayout()
+		}
+	})
+	box, err := Build(c)
+	if err != nil {
+		b.Fatal(err)
+	}
+	box.target = target
+	box.relayout()
+	b.Run("unchanged", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := box.Rebuild(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("one changed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.Markup = edits[i%2]
+			if err := box.Rebuild(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package bento
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A virtualList builds only the rows of a list that are visible in its scroll viewport
+// e.g. <list items="Lines" as="line" maxHeight="300px"><text>${line}</text></list>
+// the single child of the list is the template for each row, with ${line} and ${line.Field} replaced as for each
+type virtualList struct {
+	template    *Box
+	items       reflect.Value
+	name        string
+	rowHeight   int
+	first, last int
+	rows        map[int]*Box
+	recycled    []*Box // rows that scrolled out of view, reused for the rows that scroll into view
+	indices     []int  // the item of each visible row
+	err         error  // the first error building a row during layout, returned by Update
+}
+
+func (n *Box) buildList() error {
+	if len(n.Children) != 1 {
+		return fmt.Errorf("error building list: expected a single row template, got %d children", len(n.Children))
+	}
+	// lists built in Go are given their items, in place of the field named by the items attribute
+	spec := n.Attrs["items"]
+	items := n.items
+	if !items.IsValid() {
+		var err error
+		if items, err = lookup(reflect.ValueOf(n.Component), spec); err != nil {
+			return fmt.Errorf("error evaluating items=%q: %s", spec, err)
+		}
+	}
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return fmt.Errorf("error evaluating items=%q: expected a slice or array, got %s", spec, items.Type())
+	}
+	name := n.Attrs["as"]
+	if name == "" {
+		name = "item"
+	}
+	template := n.Children[0]
+	if template.Component == nil {
+		template.Component = n.Component
+	}
+	n.list = &virtualList{
+		template:  template,
+		items:     items,
+		name:      name,
+		rowHeight: n.Style.RowHeight,
+		rows:      make(map[int]*Box),
+	}
+	n.Children = nil
+	n.Style.Overflow = OverflowScroll
+	// build the first row up front so that errors in the template are reported by Build
+	if items.Len() > 0 {
+		if _, err := n.buildRow(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build the row for the item at index i, or return it if it's already visible
+// rows keep their state while they stay visible, and rows that scrolled out of view are recycled for the rows that
+// scroll into view, built again from the template without any of their previous state
+func (n *Box) buildRow(i int) (*Box, error) {
+	l := n.list
+	if row, ok := l.rows[i]; ok {
+		return row, nil
+	}
+	var row *Box
+	if k := len(l.recycled); k > 0 {
+		row = l.template.cloneInto(l.recycled[k-1], n)
+		l.recycled = l.recycled[:k-1]
+	} else {
+		row = l.template.clone(n)
+	}
+	if err := row.substitute(l.name, l.items.Index(i)); err != nil {
+		return nil, fmt.Errorf("error building list row %d: %s", i, err)
+	}
+	if err := row.build(nil); err != nil {
+		return nil, fmt.Errorf("error building list row %d: %s", i, err)
+	}
+	l.rows[i] = row
+	return row, nil
+}
+
+// the height of every row of the list, measured from the first row unless set with rowHeight
+func (n *Box) listRowHeight() int {
+	l := n.list
+	if l.rowHeight == 0 && l.items.Len() > 0 && l.err == nil {
+		row, err := n.buildRow(0)
+		if err != nil {
+			l.fail(err)
+			return 1
+		}
+		row.size()
+		l.rowHeight = row.OuterHeight
+	}
+	return max(l.rowHeight, 1)
+}
+
+// record the first error building a row, rows that fail to build are left out of the list
+func (l *virtualList) fail(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// replace the children of the list with the rows visible at its scroll offset
+// returns whether the visible rows changed
+func (n *Box) updateRows() bool {
+	l := n.list
+	rh := n.listRowHeight()
+	h := n.Style.MaxHeight
+	if h == 0 {
+		_, h = n.viewport()
+	}
+	first := min(n.scrollable.y/rh, l.items.Len())
+	last := min((n.scrollable.y+h)/rh+1, l.items.Len())
+	if first == l.first && last == l.last && l.indices != nil && len(n.Children) == len(l.indices) {
+		return false
+	}
+	l.first, l.last = first, last
+	for i, row := range l.rows {
+		if i < first || i >= last {
+			delete(l.rows, i)
+			l.recycled = append(l.recycled, row)
+		}
+	}
+	n.Children = n.Children[:0]
+	l.indices = make([]int, 0, last-first)
+	for i := first; i < last; i++ {
+		row, err := n.buildRow(i)
+		if err != nil {
+			l.fail(err)
+			continue
+		}
+		n.Children = append(n.Children, row)
+		l.indices = append(l.indices, i)
+	}
+	return true
+}
+
+// size the visible rows, the list's content is as tall as all of its rows
+func (n *Box) sizeList() {
+	n.updateRows()
+	for _, c := range n.Children {
+		c.size()
+		n.ContentWidth = max(n.ContentWidth, c.OuterWidth)
+	}
+	n.ContentHeight = n.list.items.Len() * n.listRowHeight()
+}
+
+func (n *Box) growList() {
+	for _, c := range n.flow() {
+		if c.Style.HGrow > 0 {
+			c.fillWidth(n.ContentWidth)
+		}
+	}
+}
+
+// place each row at its position in the list, before scrolling
+func (n *Box) justifyList() {
+	rh := n.listRowHeight()
+	for i, c := range n.Children {
+		c.Y += n.list.indices[i] * rh
+	}
+}
+
+// lay out the rows that became visible after scrolling, without changing the size of the list
+func (n *Box) scrollList() {
+	if !n.updateRows() {
+		return
+	}
+	r := n.innerRect()
+	rh := n.listRowHeight()
+	for i, c := range n.Children {
+		c.size()
+		if c.Style.HGrow > 0 {
+			c.fillWidth(n.ContentWidth)
+		}
+		c.grow()
+		c.X = r.Min.X - n.scrollable.x
+		c.Y = r.Min.Y + n.list.indices[i]*rh - n.scrollable.y
+		c.justify()
+		c.sort()
+	}
+}
@@ -0,0 +1,124 @@
+package bento
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ListComponent struct {
+	Markup   string
+	Lines    []string
+	Selected []string
+}
+
+func (c *ListComponent) Select(e *Event) {
+	c.Selected = append(c.Selected, e.Box.Attrs["name"])
+}
+
+func (c *ListComponent) UI() string {
+	return c.Markup
+}
+
+func TestVirtualList(t *testing.T) {
+	c := &ListComponent{
+		Markup: `<col>
+			<list items="Lines" as="line" maxHeight="100px" rowHeight="20px">
+				<canvas minWidth="40px" minHeight="20px" name="${line}" />
+			</list>
+		</col>`,
+	}
+	for i := 0; i < 1000; i++ {
+		c.Lines = append(c.Lines, fmt.Sprintf("line %d", i))
+	}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(200, 200)
+	box.relayout()
+	list := box.Children[0]
+	if list.OuterHeight != 100 || list.scrollHeight != 20000 {
+		t.Fatalf("got height %d scrolling %d, want 100 scrolling 20000", list.OuterHeight, list.scrollHeight)
+	}
+	checkPositions(t, list, [][2]int{{0, 0}, {0, 20}, {0, 40}, {0, 60}, {0, 80}, {0, 100}})
+	visible := list.Children[2]
+	list.scrollTo(0, 30)
+	if list.Children[1] != visible {
+		t.Fatal("expected rows that stay visible to keep their boxes")
+	}
+	rows := make(map[*Box]bool)
+	for _, row := range list.Children {
+		row.state = hover
+		rows[row] = true
+	}
+	list.scrollTo(0, 510)
+	checkPositions(t, list, [][2]int{{0, -10}, {0, 10}, {0, 30}, {0, 50}, {0, 70}, {0, 90}})
+	if got := list.Children[0].Attrs["name"]; got != "line 25" {
+		t.Fatalf("got first row %q, want line 25", got)
+	}
+	for i, row := range list.Children {
+		if !rows[row] {
+			t.Fatalf("row %d wasn't recycled from the rows that scrolled out of view", i)
+		}
+		if row.state != idle || row.Attrs["name"] != fmt.Sprintf("line %d", 25+i) {
+			t.Fatalf("recycled row %d kept state %d and name %q", i, row.state, row.Attrs["name"])
+		}
+	}
+	if _, err := Build(&ListComponent{Markup: `<list items="Lines"><text>a</text><text>b</text></list>`}); err == nil {
+		t.Fatal("expected an error for a list with more than one row template")
+	}
+}
+
+func TestListRowEvents(t *testing.T) {
+	c := &ListComponent{
+		Markup: `<list items="Lines" as="line" maxHeight="100px" rowHeight="20px">
+			<row><canvas minWidth="40px" minHeight="20px" name="${line}" onClick="Select" /></row>
+		</list>`,
+		Lines: []string{"a", "b", "c"},
+	}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(200, 200)
+	box.relayout()
+	cell := box.Children[1].Children[0]
+	if cell.Component != c {
+		t.Fatal("expected rows to belong to the list's component")
+	}
+	if !cell.fireEvent(Click, "", nil, nil) || len(c.Selected) != 1 || c.Selected[0] != "b" {
+		t.Fatalf("got selected %v, want the clicked row b", c.Selected)
+	}
+}
+
+type ItemsComponent struct {
+	Items []interface{}
+}
+
+func (c *ItemsComponent) UI() string {
+	return `<list items="Items" maxHeight="100px" rowHeight="20px"><text>${item.Name}</text></list>`
+}
+
+func TestListRowError(t *testing.T) {
+	c := &ItemsComponent{}
+	for i := 0; i < 50; i++ {
+		c.Items = append(c.Items, struct{ Name string }{fmt.Sprint(i)})
+	}
+	c.Items[30] = 30
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.target = ebiten.NewImage(200, 200)
+	box.relayout()
+	box.scrollTo(0, 500)
+	if box.list.err == nil {
+		t.Fatal("expected an error for a row that can't be built")
+	}
+	if len(box.Children) != 5 {
+		t.Fatalf("got %d rows, want the rows that could be built", len(box.Children))
+	}
+	checkPositions(t, box, [][2]int{{0, 0}, {0, 20}, {0, 40}, {0, 60}, {0, 80}})
+}
@@ -2,6 +2,7 @@ package bento
 
 import (
 	"image"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -29,12 +30,13 @@ import (
 */
 
 // A NineSlice is an image that can be drawn with any width and height. It is basically a 3x3 grid of image tiles:
-// The corner tiles are drawn as-is, while the center columns and rows of tiles will be stretched to fit the desired
+// The corner tiles are drawn as-is, or scaled by the UI scale, while the center columns and rows of tiles will be stretched to fit the desired
 // width and height.
 type NineSlice struct {
 	widths  [3]int
 	heights [3]int
 	tiles   [9]*ebiten.Image
+	scale   float64
 }
 
 func NewNineSlice(img *ebiten.Image, widths, heights [3]int, offsetX, offsetY int) *NineSlice {
@@ -71,17 +73,17 @@ func (n *NineSlice) Draw(screen *ebiten.Image, x, y, width, height int, op *ebit
 
 		var th int
 		if r == 1 {
-			th = height - n.heights[0] - n.heights[2]
+			th = height - n.px(n.heights[0]) - n.px(n.heights[2])
 		} else {
-			th = sh
+			th = n.px(sh)
 		}
 
 		for c, sw := range n.widths {
 			var tw int
 			if c == 1 {
-				tw = width - n.widths[0] - n.widths[2]
+				tw = width - n.px(n.widths[0]) - n.px(n.widths[2])
 			} else {
-				tw = sw
+				tw = n.px(sw)
 			}
 
 			n.drawTile(screen, n.tiles[r*3+c], tx, ty, sw, sh, tw, th, op)
@@ -96,11 +98,29 @@ func (n *NineSlice) Draw(screen *ebiten.Image, x, y, width, height int, op *ebit
 }
 
 func (n *NineSlice) Width() int {
-	return n.widths[0] + n.widths[1] + n.widths[2]
+	return n.px(n.widths[0] + n.widths[1] + n.widths[2])
 }
 
 func (n *NineSlice) Height() int {
-	return n.heights[0] + n.heights[1] + n.heights[2]
+	return n.px(n.heights[0] + n.heights[1] + n.heights[2])
+}
+
+// a copy of the nine slice that draws its tiles scaled, sharing the same image
+func (n *NineSlice) withScale(scale float64) *NineSlice {
+	if n == nil {
+		return nil
+	}
+	scaled := *n
+	scaled.scale = scale
+	return &scaled
+}
+
+// the size in pixels of a length of the image when drawn
+func (n *NineSlice) px(v int) int {
+	if n.scale == 0 {
+		return v
+	}
+	return int(math.Round(float64(v) * n.scale))
 }
 
 func (n *NineSlice) drawTile(screen *ebiten.Image, tile *ebiten.Image, tx int, ty int, sw int, sh int, tw int, th int, op *ebiten.DrawImageOptions) {
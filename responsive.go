@@ -0,0 +1,110 @@
+package bento
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Breakpoints are the minimum screen widths of the prefixes of responsive attributes
+// e.g. lg:padding="24px" replaces padding when the screen is at least 1024px wide
+var Breakpoints = map[string]int{
+	"sm": 640,
+	"md": 768,
+	"lg": 1024,
+	"xl": 1280,
+}
+
+// whether the box has any attributes qualified by a breakpoint
+func (n *Box) isResponsive() (bool, error) {
+	responsive := false
+	for k := range n.Attrs {
+		if i := strings.Index(k, ":"); i >= 0 {
+			if _, ok := Breakpoints[k[:i]]; !ok {
+				return false, fmt.Errorf("unknown breakpoint %s in %s", k[:i], k)
+			}
+			responsive = true
+		}
+	}
+	return responsive, nil
+}
+
+// the breakpoint of the screen width, measured in unscaled pixels like the breakpoints
+func (n *Box) currentBreakpoint() string {
+	vw, _ := n.viewport()
+	return breakpoint(int(float64(vw) / n.Scale()))
+}
+
+// the largest breakpoint the screen width reaches, or "" if it is narrower than all of them
+func breakpoint(width int) string {
+	bp := ""
+	for name, min := range Breakpoints {
+		if width >= min && (bp == "" || min > Breakpoints[bp]) {
+			bp = name
+		}
+	}
+	return bp
+}
+
+// replace attributes with those qualified by each breakpoint up to and including bp, smallest first
+func (s *Style) applyBreakpoint(bp string) {
+	if bp == "" {
+		return
+	}
+	var keys []string
+	for k := range s.Attrs {
+		if i := strings.Index(k, ":"); i >= 0 && Breakpoints[k[:i]] <= Breakpoints[bp] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return Breakpoints[keys[i][:strings.Index(keys[i], ":")]] < Breakpoints[keys[j][:strings.Index(keys[j], ":")]]
+	})
+	for _, k := range keys {
+		s.Attrs[k[strings.Index(k, ":")+1:]] = s.Attrs[k]
+	}
+}
+
+// parse the style of the box from its attributes at the breakpoint
+func (n *Box) restyle(bp string) error {
+	s := n.base
+	s.Attrs = make(map[string]string)
+	for k, v := range n.base.Attrs {
+		s.Attrs[k] = v
+	}
+	s.adopt(n)
+	s.applyBreakpoint(bp)
+	if err := s.parseAttributes(); err != nil {
+		if bp != "" {
+			return fmt.Errorf("%s at breakpoint %s", err, bp)
+		}
+		return err
+	}
+	n.Style = s
+	n.breakpoint = bp
+	return nil
+}
+
+// parse the style of a responsive box at every breakpoint, so that errors are reported when it is built
+func (n *Box) checkBreakpoints() error {
+	current := n.breakpoint
+	for bp := range Breakpoints {
+		if err := n.restyle(bp); err != nil {
+			return err
+		}
+	}
+	return n.restyle(current)
+}
+
+// restyle responsive boxes when the screen width crosses a breakpoint
+func (n *Box) applyBreakpoints() {
+	if !n.responsive {
+		return
+	}
+	if bp := n.currentBreakpoint(); bp != n.breakpoint {
+		if err := n.restyle(bp); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
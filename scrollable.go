@@ -8,13 +8,25 @@ import (
 )
 
 type Scrollable struct {
-	state    [4]State
-	line     int
-	position float64
+	state      [4]State
+	line       int
+	position   float64
+	hstate     [4]State
+	x, y       int
+	drag       int
+	dragFrom   image.Point
+	dragOffset image.Point
 }
 
-func (s *Scrollable) update(b *Box) error {
-	if b.Style.Scrollbar == nil || b.Attrs["disabled"] == "true" {
+func (s *Scrollable) update(b *Box, ctx *context) error {
+	if b.Attrs["disabled"] == "true" {
+		return nil
+	}
+	if b.Style.Overflow == OverflowScroll && b.Tag != "p" && b.Tag != "textarea" {
+		s.updateViewport(b, ctx)
+		return nil
+	}
+	if b.Style.Scrollbar == nil {
 		return nil
 	}
 	mt, ml := b.Style.Margin.Top, b.Style.Margin.Left
@@ -27,8 +39,7 @@ func (s *Scrollable) update(b *Box) error {
 		// TODO: the math here works out but it's confusing
 		r := rects[i].Add(image.Pt(b.X+ml+pl+pl, b.Y+mt+pt-pt))
 		s.state[i] = idle
-		x, y := ebiten.CursorPosition()
-		if inside(r, x, y) {
+		if x, y, ok := b.cursor(); ok && inside(r, x, y) {
 			s.state[i] = hover
 			if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 				s.state[i] = active
@@ -47,3 +58,178 @@ func (s *Scrollable) update(b *Box) error {
 	}
 	return nil
 }
+
+// the axes along which a scrolling box lays out its content without bounds
+// boxes scroll along their main axis, or their cross axis if their children wrap
+func (n *Box) scrollAxes() (bool, bool) {
+	horizontal := n.Tag == "row"
+	if n.Style.Wrap {
+		horizontal = !horizontal
+	}
+	return horizontal, !horizontal
+}
+
+// measure the extent of the children and move them by the scroll offset
+func (n *Box) scrollChildren() {
+	r := n.innerRect()
+	n.scrollWidth, n.scrollHeight = 0, 0
+	for _, c := range n.Children {
+		if !c.Style.Display {
+			continue
+		}
+		n.scrollWidth = max(n.scrollWidth, c.X+c.OuterWidth-r.Min.X)
+		n.scrollHeight = max(n.scrollHeight, c.Y+c.OuterHeight-r.Min.Y)
+	}
+	if n.list != nil {
+		n.scrollHeight = n.list.items.Len() * n.listRowHeight()
+	}
+	x, y := n.scrollable.x, n.scrollable.y
+	n.scrollable.x, n.scrollable.y = 0, 0
+	n.scrollTo(x, y)
+}
+
+// scroll the children of the box to the offset, clamped to the extent of the children
+func (n *Box) scrollTo(x, y int) {
+	mx, my := n.maxScroll()
+	x = max(0, min(x, mx))
+	y = max(0, min(y, my))
+	for _, c := range n.Children {
+		c.translate(n.scrollable.x-x, n.scrollable.y-y)
+	}
+	n.scrollable.x, n.scrollable.y = x, y
+	if n.list != nil {
+		n.scrollList()
+	}
+}
+
+func (n *Box) translate(dx, dy int) {
+	n.X += dx
+	n.Y += dy
+	for _, c := range n.Children {
+		c.translate(dx, dy)
+	}
+}
+
+// the size of the area the children are visible in, less the space taken by the scrollbars
+func (n *Box) viewportSize() (int, int, bool, bool) {
+	w, h := n.InnerWidth, n.InnerHeight
+	if n.Style.Scrollbar == nil {
+		return w, h, false, false
+	}
+	s := n.Style.Scrollbar[0][0].Width()
+	vertical := n.scrollHeight > h
+	horizontal := n.scrollWidth > w
+	if vertical {
+		w -= s
+		horizontal = n.scrollWidth > w
+	}
+	if horizontal {
+		h -= s
+		if !vertical && n.scrollHeight > h {
+			vertical = true
+			w -= s
+		}
+	}
+	return w, h, vertical, horizontal
+}
+
+func (n *Box) maxScroll() (int, int) {
+	w, h, _, _ := n.viewportSize()
+	return max(0, n.scrollWidth-w), max(0, n.scrollHeight-h)
+}
+
+// the vertical and horizontal scrollbars of a scrolling box, in screen coordinates
+// each has the same up button, track, handle and down button as a scrolling paragraph, the horizontal scrollbar has
+// no buttons
+func (n *Box) viewportScrollRects() ([2][4]image.Rectangle, [2]bool) {
+	var rects [2][4]image.Rectangle
+	w, h, vertical, horizontal := n.viewportSize()
+	if !vertical && !horizontal {
+		return rects, [2]bool{}
+	}
+	s := n.Style.Scrollbar[0][0].Width()
+	r := n.innerRect()
+	mx, my := n.maxScroll()
+	if vertical {
+		x := r.Min.X + w
+		rects[0][0] = image.Rect(x, r.Min.Y, x+s, r.Min.Y+s)
+		rects[0][1] = image.Rect(x, r.Min.Y+s, x+s, r.Min.Y+h-s)
+		rects[0][3] = image.Rect(x, r.Min.Y+h-s, x+s, r.Min.Y+h)
+		track := rects[0][1].Dy()
+		length := max(s, track*h/max(n.scrollHeight, 1))
+		pos := 0
+		if my > 0 {
+			pos = (track - length) * n.scrollable.y / my
+		}
+		rects[0][2] = image.Rect(x, rects[0][1].Min.Y+pos, x+s, rects[0][1].Min.Y+pos+length)
+	}
+	if horizontal {
+		y := r.Min.Y + h
+		rects[1][1] = image.Rect(r.Min.X, y, r.Min.X+w, y+s)
+		track := rects[1][1].Dx()
+		length := max(s, track*w/max(n.scrollWidth, 1))
+		pos := 0
+		if mx > 0 {
+			pos = (track - length) * n.scrollable.x / mx
+		}
+		rects[1][2] = image.Rect(r.Min.X+pos, y, r.Min.X+pos+length, y+s)
+	}
+	return rects, [2]bool{vertical, horizontal}
+}
+
+// scroll the viewport with the mouse wheel, the scrollbar buttons, or by dragging the handles
+func (s *Scrollable) updateViewport(b *Box, ctx *context) {
+	x, y, ok := b.cursor()
+	step := b.Style.Font.Metrics().Height.Round()
+	sx, sy := s.x, s.y
+	if wx, wy := ebiten.Wheel(); (wx != 0 || wy != 0) && ok && !ctx.consumed && inside(b.innerRect(), x, y) && !b.clipped(x, y) {
+		sx -= int(wx * float64(step))
+		sy -= int(wy * float64(step))
+		ctx.consumed = true
+	}
+	pressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	clicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	if !pressed {
+		s.drag = 0
+	}
+	rects, visible := b.viewportScrollRects()
+	states := [2]*[4]State{&s.state, &s.hstate}
+	for axis, bar := range rects {
+		for i, r := range bar {
+			states[axis][i] = idle
+			if !visible[axis] || r.Empty() || !ok || !inside(r, x, y) || b.clipped(x, y) {
+				continue
+			}
+			states[axis][i] = hover
+			if pressed {
+				states[axis][i] = active
+			}
+			if !clicked {
+				continue
+			}
+			ctx.consumed = true
+			switch i {
+			case 0:
+				sy -= step
+			case 2:
+				s.drag = axis + 1
+				s.dragFrom = image.Pt(x, y)
+				s.dragOffset = image.Pt(s.x, s.y)
+			case 3:
+				sy += step
+			}
+		}
+	}
+	if s.drag != 0 && ok {
+		mx, my := b.maxScroll()
+		handle, track := rects[s.drag-1][2], rects[s.drag-1][1]
+		if s.drag == 1 && track.Dy() > handle.Dy() {
+			sy = s.dragOffset.Y + (y-s.dragFrom.Y)*my/(track.Dy()-handle.Dy())
+		} else if s.drag == 2 && track.Dx() > handle.Dx() {
+			sx = s.dragOffset.X + (x-s.dragFrom.X)*mx/(track.Dx()-handle.Dx())
+		}
+	}
+	if sx != s.x || sy != s.y {
+		b.scrollTo(sx, sy)
+	}
+}
@@ -3,6 +3,7 @@ package bento
 import (
 	"fmt"
 	"image/color"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -15,7 +16,7 @@ import (
 )
 
 var (
-	sizeSpec = regexp.MustCompile(`(\d+)(em|px|lh)`)
+	sizeSpec = regexp.MustCompile(`^(-?(?:\d+(?:\.\d+)?|\.\d+))(em|px|lh|%|vw|vh)$`)
 )
 
 type Justification string
@@ -44,10 +45,37 @@ const (
 	// The first item is flush with the main-start edge, and the last item is flush with the main-end edge.
 	// [item1               item2]
 	Between = Justification("between")
+	// The items are packed toward the start edge of the alignment container and fill it along the cross axis.
+	// Along the main axis, stretch is the same as start.
+	// [item1 item2              ]
+	Stretch = Justification("stretch")
+	// The items are aligned along the cross axis of a row so that the baselines of their first lines of text match.
+	// Along the main axis, or the cross axis of a col, baseline is the same as start.
+	Baseline = Justification("baseline")
+)
+
+type Overflow string
+
+const (
+	// Children are drawn outside the box if they don't fit
+	OverflowVisible = Overflow("visible")
+	// Children are clipped to the inner bounds of the box, and don't receive events outside of them
+	OverflowHidden = Overflow("hidden")
+	// Children are clipped like OverflowHidden, and can be scrolled into view
+	OverflowScroll = Overflow("scroll")
 )
 
+func (o Overflow) Valid() bool {
+	return o == OverflowVisible || o == OverflowHidden || o == OverflowScroll
+}
+
+// whether children are clipped to the inner bounds of the box
+func (s *Style) clips() bool {
+	return s.Overflow == OverflowHidden || s.Overflow == OverflowScroll
+}
+
 func (j Justification) Valid() bool {
-	return j == "start" || j == "end" || j == "center" || j == "between" || j == "around" || j == "evenly"
+	return j == "start" || j == "end" || j == "center" || j == "between" || j == "around" || j == "evenly" || j == "stretch" || j == "baseline"
 }
 
 type Spacing struct {
@@ -68,20 +96,162 @@ type Style struct {
 	Image                *ebiten.Image
 	MinWidth, MinHeight  int
 	MaxWidth, MaxHeight  int
+	RowHeight            int
+	Aspect               float64
 	HJust, VJust         Justification
 	HJustSelf, VJustSelf Justification
 	HGrow, VGrow         int
+	HShrink, VShrink     int
+	Wrap                 bool
+	Reverse              bool
+	Order                int
+	HGap, VGap           int
+	Columns, Rows        []Track
+	ColSpan, RowSpan     int
+	AlignContent         Justification
 	Margin, Padding      Spacing
 	Color                color.Color
 	OffsetX, OffsetY     int
+	Overflow             Overflow
 	Float                bool
+	Anchor               *Anchor
 	Hidden               bool
 	Display              bool
 	ScaleX, ScaleY       float64
 	ZIndex               int
+	relative             map[lengthField]Length
 	node                 *Box
 }
 
+// A Length is a size attribute, e.g. "1.5em" or "50%"
+// Lengths in %, vw and vh are relative to the layout and resolved to pixels when the box is sized
+type Length struct {
+	Value float64
+	Unit  string
+}
+
+type lengthField int
+
+const (
+	marginTop lengthField = iota
+	marginRight
+	marginBottom
+	marginLeft
+	paddingTop
+	paddingRight
+	paddingBottom
+	paddingLeft
+	minWidth
+	minHeight
+	maxWidth
+	maxHeight
+	offsetX
+	offsetY
+)
+
+// the style field a length resolves to, and whether it is measured horizontally
+func (s *Style) lengthField(f lengthField) (*int, bool) {
+	switch f {
+	case marginTop:
+		return &s.Margin.Top, false
+	case marginRight:
+		return &s.Margin.Right, true
+	case marginBottom:
+		return &s.Margin.Bottom, false
+	case marginLeft:
+		return &s.Margin.Left, true
+	case paddingTop:
+		return &s.Padding.Top, false
+	case paddingRight:
+		return &s.Padding.Right, true
+	case paddingBottom:
+		return &s.Padding.Bottom, false
+	case paddingLeft:
+		return &s.Padding.Left, true
+	case minWidth:
+		return &s.MinWidth, true
+	case minHeight:
+		return &s.MinHeight, false
+	case maxWidth:
+		return &s.MaxWidth, true
+	case maxHeight:
+		return &s.MaxHeight, false
+	case offsetX:
+		return &s.OffsetX, true
+	case offsetY:
+		return &s.OffsetY, false
+	}
+	panic(fmt.Errorf("unknown length field %d", f))
+}
+
+// set the field to the length in pixels, or defer relative lengths until layout
+func (s *Style) setLength(f lengthField, l Length) {
+	field, _ := s.lengthField(f)
+	if l.relative() {
+		s.relative[f] = l
+		*field = 0
+		return
+	}
+	delete(s.relative, f)
+	*field = l.pixels(s.Font, s.scale(), 0, 0, 0)
+}
+
+// whether any length of the style is a percentage of the parent's content box
+func (s *Style) hasPercent() bool {
+	for _, l := range s.relative {
+		if l.Unit == "%" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Style) parseLength(attr string, f lengthField) error {
+	l, err := parseLength(s.Attrs[attr])
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %s", attr, err)
+	}
+	s.setLength(f, l)
+	return nil
+}
+
+// the UI scale of the tree the style belongs to
+func (s *Style) scale() float64 {
+	if s.node == nil {
+		return 1
+	}
+	return s.node.Scale()
+}
+
+// draw the nine slices of the style at the UI scale
+func (s *Style) scaleNineSlices() {
+	scale := s.scale()
+	s.Border = s.Border.withScale(scale)
+	if s.Button != nil {
+		var button [4]*NineSlice
+		for i, b := range s.Button {
+			button[i] = b.withScale(scale)
+		}
+		s.Button = &button
+	}
+	if s.Input != nil {
+		var input [4]*NineSlice
+		for i, b := range s.Input {
+			input[i] = b.withScale(scale)
+		}
+		s.Input = &input
+	}
+	if s.Scrollbar != nil {
+		var scrollbar [3][4]*NineSlice
+		for i, states := range s.Scrollbar {
+			for j, b := range states {
+				scrollbar[i][j] = b.withScale(scale)
+			}
+		}
+		s.Scrollbar = &scrollbar
+	}
+}
+
 func (s *Style) adopt(node *Box) {
 	if s.Attrs == nil {
 		s.Attrs = make(map[string]string)
@@ -114,9 +284,38 @@ func (n *Box) styleSize() {
 		minHeight := metrics.Height.Round()
 		n.ContentHeight = max(n.ContentHeight, minHeight)
 	}
+	if n.Style.MaxWidth > 0 && n.ContentWidth > n.Style.MaxWidth {
+		n.ContentWidth = n.Style.MaxWidth
+	}
 	if n.Style.MaxHeight > 0 && n.ContentHeight > n.Style.MaxHeight {
 		n.ContentHeight = n.Style.MaxHeight
 	}
+	n.ContentWidth, n.ContentHeight = n.Style.aspectSize(n.ContentWidth, n.ContentHeight, false)
+	n.ContentWidth = max(n.ContentWidth, n.aspectMin.X)
+	n.ContentHeight = max(n.ContentHeight, n.aspectMin.Y)
+}
+
+// adjust a content size to the aspect ratio of the box, within its max size
+// the shorter side is lengthened, or with fit the longer side is shortened
+func (s *Style) aspectSize(w, h int, fit bool) (int, int) {
+	a := s.Aspect
+	if a <= 0 {
+		return w, h
+	}
+	if wide := float64(w) > float64(h)*a; wide != fit {
+		h = int(math.Round(float64(w) / a))
+	} else {
+		w = int(math.Round(float64(h) * a))
+	}
+	if s.MaxWidth > 0 && w > s.MaxWidth {
+		w = s.MaxWidth
+		h = int(math.Round(float64(w) / a))
+	}
+	if s.MaxHeight > 0 && h > s.MaxHeight {
+		h = s.MaxHeight
+		w = int(math.Round(float64(h) * a))
+	}
+	return w, h
 }
 
 func (s *Style) parseAttributes() error {
@@ -127,27 +326,32 @@ func (s *Style) parseAttributes() error {
 			if s.FontSize > 0 {
 				size = s.FontSize
 			}
-			s.Font = bentotext.Font(s.FontName, size)
+			s.Font = bentotext.Font(s.FontName, scaleFont(size, s.scale()))
 		}
 	}
 	if s.Font == nil {
-		if s.FontName, s.FontSize, s.Font, err = parseFont(s.Attrs["font"]); err != nil {
+		if s.FontName, s.FontSize, s.Font, err = parseFont(s.Attrs["font"], s.scale()); err != nil {
 			return fmt.Errorf("error parsing font: %s", err)
 		}
 	}
 	if spec := s.Attrs["underline"]; spec == "true" {
 		s.Underline = true
 	}
-	margin, err := parseSpacing(s.Attrs["margin"], s.Font)
+	s.relative = make(map[lengthField]Length)
+	margin, err := parseSpacing(s.Attrs["margin"])
 	if err != nil {
 		return fmt.Errorf("error parsing margin: %s", err)
 	}
-	s.Margin = *margin
-	padding, err := parseSpacing(s.Attrs["padding"], s.Font)
+	for i, l := range margin {
+		s.setLength(marginTop+lengthField(i), l)
+	}
+	padding, err := parseSpacing(s.Attrs["padding"])
 	if err != nil {
 		return fmt.Errorf("error parsing padding: %s", err)
 	}
-	s.Padding = *padding
+	for i, l := range padding {
+		s.setLength(paddingTop+lengthField(i), l)
+	}
 	if s.Image == nil {
 		if s.Image, err = loadImage(s.Attrs["src"]); err != nil {
 			return fmt.Errorf("error parsing image src: %s", err)
@@ -159,30 +363,41 @@ func (s *Style) parseAttributes() error {
 		}
 	}
 	if s.MinWidth == 0 {
-		if s.MinWidth, err = parseSize(s.Attrs["minWidth"], s.Font); err != nil {
-			return fmt.Errorf("error parsing minWidth: %s", err)
+		if err := s.parseLength("minWidth", minWidth); err != nil {
+			return err
 		}
 	}
 	if s.MinHeight == 0 {
-		if s.MinHeight, err = parseSize(s.Attrs["minHeight"], s.Font); err != nil {
-			return fmt.Errorf("error parsing minHeight: %s", err)
+		if err := s.parseLength("minHeight", minHeight); err != nil {
+			return err
 		}
 	}
 	if s.MaxWidth == 0 {
-		if s.MaxWidth, err = parseSize(s.Attrs["maxWidth"], s.Font); err != nil {
-			return fmt.Errorf("error parsing maxWidth: %s", err)
+		if err := s.parseLength("maxWidth", maxWidth); err != nil {
+			return err
 		}
 	}
-	if s.MaxWidth != 0 && s.node.Tag != "p" && s.node.Tag != "textarea" {
-		return fmt.Errorf("invalid tag %s: max width can only apply to paragraph (p) or textarea", s.node.Tag)
-	}
 	if s.MaxHeight == 0 {
-		if s.MaxHeight, err = parseSize(s.Attrs["maxHeight"], s.Font); err != nil {
-			return fmt.Errorf("error parsing maxHeight: %s", err)
+		if err := s.parseLength("maxHeight", maxHeight); err != nil {
+			return err
 		}
 	}
-	if s.MaxHeight != 0 && s.node.Tag != "p" && s.node.Tag != "textarea" {
-		return fmt.Errorf("invalid tag %s: max height can only apply to paragraph (p) or textarea", s.node.Tag)
+	if spec := s.Attrs["overflow"]; spec != "" {
+		s.Overflow = Overflow(spec)
+		if !s.Overflow.Valid() {
+			return fmt.Errorf("error parsing overflow: invalid overflow %s", spec)
+		}
+	}
+	if s.Overflow == "" {
+		s.Overflow = OverflowVisible
+	}
+	if spec := s.Attrs["rowHeight"]; spec != "" && s.RowHeight == 0 {
+		if s.RowHeight, err = parseSize(spec, s.Font, s.scale()); err != nil {
+			return fmt.Errorf("error parsing rowHeight: %s", err)
+		}
+		if s.node.Tag != "list" {
+			return fmt.Errorf("invalid tag %s: rowHeight can only apply to list", s.node.Tag)
+		}
 	}
 	if spec := s.Attrs["justify"]; spec != "" {
 		if s.HJust, s.VJust, err = parseJustification(spec); err != nil {
@@ -206,20 +421,90 @@ func (s *Style) parseAttributes() error {
 	if s.VJustSelf == "" {
 		s.VJustSelf = Start
 	}
+	if spec := s.Attrs["wrap"]; spec != "" {
+		if s.Wrap, err = parseBool(spec); err != nil {
+			return fmt.Errorf("error parsing wrap: %s", err)
+		}
+	}
+	if s.Wrap && s.node.Tag != "row" && s.node.Tag != "col" {
+		return fmt.Errorf("invalid tag %s: wrap can only apply to row or col", s.node.Tag)
+	}
+	if spec := s.Attrs["direction"]; spec != "" {
+		if spec != "normal" && spec != "reverse" {
+			return fmt.Errorf("error parsing direction: invalid direction %s, expected normal or reverse", spec)
+		}
+		s.Reverse = spec == "reverse"
+	}
+	if s.Reverse && s.node.Tag != "row" && s.node.Tag != "col" {
+		return fmt.Errorf("invalid tag %s: direction can only apply to row or col", s.node.Tag)
+	}
+	if spec := s.Attrs["order"]; spec != "" {
+		if s.Order, err = strconv.Atoi(spec); err != nil {
+			return fmt.Errorf("error parsing order: %s", err)
+		}
+	}
+	if spec := s.Attrs["gap"]; spec != "" {
+		if s.HGap, s.VGap, err = parseGap(spec, s.Font, s.scale()); err != nil {
+			return fmt.Errorf("error parsing gap: %s", err)
+		}
+		if s.node.Tag != "row" && s.node.Tag != "col" && s.node.Tag != "grid" {
+			return fmt.Errorf("invalid tag %s: gap can only apply to row, col or grid", s.node.Tag)
+		}
+	}
+	if spec := s.Attrs["alignContent"]; spec != "" {
+		s.AlignContent = Justification(spec)
+		if !s.AlignContent.Valid() {
+			return fmt.Errorf("error parsing alignContent: invalid justification %s", spec)
+		}
+	}
+	if s.AlignContent == "" {
+		s.AlignContent = Start
+	}
+	if spec := s.Attrs["columns"]; spec != "" && s.Columns == nil {
+		if s.Columns, err = parseTracks(spec, s.Font, s.scale()); err != nil {
+			return fmt.Errorf("error parsing columns: %s", err)
+		}
+	}
+	if spec := s.Attrs["rows"]; spec != "" && s.Rows == nil {
+		if s.Rows, err = parseTracks(spec, s.Font, s.scale()); err != nil {
+			return fmt.Errorf("error parsing rows: %s", err)
+		}
+	}
+	if (s.Columns != nil || s.Rows != nil) && s.node.Tag != "grid" {
+		return fmt.Errorf("invalid tag %s: columns and rows can only apply to grid", s.node.Tag)
+	}
+	if spec := s.Attrs["colSpan"]; spec != "" {
+		if s.ColSpan, err = strconv.Atoi(spec); err != nil {
+			return fmt.Errorf("error parsing colSpan: %s", err)
+		}
+	}
+	if spec := s.Attrs["rowSpan"]; spec != "" {
+		if s.RowSpan, err = strconv.Atoi(spec); err != nil {
+			return fmt.Errorf("error parsing rowSpan: %s", err)
+		}
+	}
 	if spec := s.Attrs["grow"]; spec != "" {
 		if s.HGrow, s.VGrow, err = parseGrow(spec); err != nil {
 			return fmt.Errorf("error parsing grow: %s", err)
 		}
 	}
+	if spec := s.Attrs["shrink"]; spec != "" {
+		if s.HShrink, s.VShrink, err = parseGrow(spec); err != nil {
+			return fmt.Errorf("error parsing shrink: %s", err)
+		}
+	}
 	if s.Color == nil {
 		if s.Color, err = parseColor(s.Attrs["color"]); err != nil {
 			return fmt.Errorf("error parsing color: %s", err)
 		}
 	}
 	if spec := s.Attrs["offset"]; spec != "" {
-		if s.OffsetX, s.OffsetY, err = parseOffset(spec); err != nil {
+		x, y, err := parseOffset(spec)
+		if err != nil {
 			return fmt.Errorf("error parsing offset: %s", err)
 		}
+		s.setLength(offsetX, x)
+		s.setLength(offsetY, y)
 	}
 	if spec := s.Attrs["scale"]; spec != "" {
 		if s.ScaleX, s.ScaleY, err = parseScale(spec); err != nil {
@@ -228,6 +513,16 @@ func (s *Style) parseAttributes() error {
 	} else {
 		s.ScaleX, s.ScaleY = 1, 1
 	}
+	if spec := s.Attrs["aspect"]; spec != "" && s.Aspect == 0 {
+		if s.Aspect, err = parseAspect(spec); err != nil {
+			return fmt.Errorf("error parsing aspect: %s", err)
+		}
+	}
+	// images that grow keep their intrinsic ratio
+	if s.Aspect == 0 && s.Image != nil && (s.HGrow > 0 || s.VGrow > 0) {
+		bounds := s.Image.Bounds()
+		s.Aspect = float64(bounds.Dx()) * s.ScaleX / (float64(bounds.Dy()) * s.ScaleY)
+	}
 	if spec := s.Attrs["zIndex"]; spec != "" {
 		s.ZIndex, err = strconv.Atoi(spec)
 		if err != nil {
@@ -249,16 +544,38 @@ func (s *Style) parseAttributes() error {
 			return fmt.Errorf("error parsing input: %s", err)
 		}
 	}
+	s.scaleNineSlices()
 	s.Float = s.Attrs["float"] == "true"
+	if spec := s.Attrs["anchor"]; spec != "" && s.Anchor == nil {
+		if s.Anchor, err = parseAnchor(spec); err != nil {
+			return fmt.Errorf("error parsing anchor: %s", err)
+		}
+	}
+	// anchored boxes are placed next to their target, outside the flow of their parent
+	s.Float = s.Float || s.Anchor != nil
 	s.Hidden = s.Attrs["hidden"] == "true"
 	s.Display = s.Attrs["display"] != "false"
+	if spec, ok := s.Attrs["show"]; ok {
+		show, err := parseBool(spec)
+		if err != nil {
+			return fmt.Errorf("error parsing show: %s", err)
+		}
+		s.Display = s.Display && show
+	}
+	if spec, ok := s.Attrs["hide"]; ok {
+		hide, err := parseBool(spec)
+		if err != nil {
+			return fmt.Errorf("error parsing hide: %s", err)
+		}
+		s.Display = s.Display && !hide
+	}
 	return nil
 }
 
 // parse font spec e.g. "NotoSans 16"
-func parseFont(spec string) (string, int, font.Face, error) {
+func parseFont(spec string, scale float64) (string, int, font.Face, error) {
 	if spec == "" {
-		return "NotoSans", 16, bentotext.Font("NotoSans", 16), nil
+		return "NotoSans", 16, bentotext.Font("NotoSans", scaleFont(16, scale)), nil
 	}
 	a := strings.Split(spec, " ")
 	if len(a) != 2 {
@@ -268,54 +585,61 @@ func parseFont(spec string) (string, int, font.Face, error) {
 	if err != nil {
 		return "", 0, nil, err
 	}
-	return a[1], size, bentotext.Font(a[0], size), nil
+	return a[1], size, bentotext.Font(a[0], scaleFont(size, scale)), nil
+}
+
+func scaleFont(size int, scale float64) int {
+	return max(1, int(math.Round(float64(size)*scale)))
 }
 
 // parse spacing spec e.g. "24px", "12px 12px", "8px 24px 6px 12px"
-// units can be px or em
-func parseSpacing(spec string, font font.Face) (*Spacing, error) {
+// into top, right, bottom and left lengths
+func parseSpacing(spec string) ([4]Length, error) {
+	var spacing [4]Length
 	if spec == "" {
-		return &Spacing{0, 0, 0, 0}, nil
+		return spacing, nil
 	}
 	a := strings.Split(spec, " ")
-	if len(a) == 1 {
-		size, err := parseSize(a[0], font)
-		if err != nil {
-			return nil, err
-		}
-		return &Spacing{size, size, size, size}, nil
+	if len(a) != 1 && len(a) != 2 && len(a) != 4 {
+		return spacing, fmt.Errorf("invalid spacing spec %s", spec)
 	}
-	if len(a) == 2 {
-		x, err := parseSize(a[0], font)
+	lengths := make([]Length, len(a))
+	for i, s := range a {
+		l, err := parseLength(s)
 		if err != nil {
-			return nil, err
+			return spacing, err
 		}
-		y, err := parseSize(a[1], font)
-		if err != nil {
-			return nil, err
-		}
-		return &Spacing{y, x, y, x}, nil
+		lengths[i] = l
 	}
-	if len(a) == 4 {
-		top, err := parseSize(a[0], font)
-		if err != nil {
-			return nil, err
-		}
-		right, err := parseSize(a[1], font)
-		if err != nil {
-			return nil, err
-		}
-		bottom, err := parseSize(a[2], font)
-		if err != nil {
-			return nil, err
-		}
-		left, err := parseSize(a[3], font)
-		if err != nil {
-			return nil, err
+	switch len(lengths) {
+	case 1:
+		spacing = [4]Length{lengths[0], lengths[0], lengths[0], lengths[0]}
+	case 2:
+		x, y := lengths[0], lengths[1]
+		spacing = [4]Length{y, x, y, x}
+	case 4:
+		copy(spacing[:], lengths)
+	}
+	return spacing, nil
+}
+
+// parse gap spec e.g. "8px" or "8px 16px" for horizontal and vertical gaps
+func parseGap(spec string, font font.Face, scale float64) (int, int, error) {
+	a := strings.Split(spec, " ")
+	if len(a) > 2 {
+		return 0, 0, fmt.Errorf("too many parameters for gap, expected at most 2: %s", spec)
+	}
+	h, err := parseSize(a[0], font, scale)
+	if err != nil {
+		return 0, 0, err
+	}
+	v := h
+	if len(a) == 2 {
+		if v, err = parseSize(a[1], font, scale); err != nil {
+			return 0, 0, err
 		}
-		return &Spacing{top, right, bottom, left}, nil
 	}
-	return nil, fmt.Errorf("invalid spacing spec %s", spec)
+	return h, v, nil
 }
 
 func parseJustification(spec string) (Justification, Justification, error) {
@@ -351,26 +675,32 @@ func parseGrow(spec string) (int, int, error) {
 	if err == nil && len(a) == 2 {
 		vg, err = strconv.Atoi(a[1])
 	} else if len(a) > 2 {
-		return 0, 0, fmt.Errorf("too many parameters for grow, expected at most 2: %s", spec)
+		return 0, 0, fmt.Errorf("too many factors, expected at most 2: %s", spec)
 	}
 	return hg, vg, err
 }
 
-func parseOffset(spec string) (int, int, error) {
-	if spec == "" {
-		return 0, 0, nil
-	}
+// parse offset spec e.g. "4 12", "-4px 10%"
+// numbers without units are pixels, negative offsets are from the end of the parent
+func parseOffset(spec string) (Length, Length, error) {
 	a := strings.Split(spec, " ")
-	var x, y int
-	var err error
-	x, err = strconv.Atoi(a[0])
-	y = x
-	if err == nil && len(a) == 2 {
-		y, err = strconv.Atoi(a[1])
-	} else if len(a) > 2 {
-		return 0, 0, fmt.Errorf("too many parameters for offset, expected at most 2: %s", spec)
+	if len(a) > 2 {
+		return Length{}, Length{}, fmt.Errorf("too many parameters for offset, expected at most 2: %s", spec)
 	}
-	return x, y, err
+	var lengths [2]Length
+	for i, s := range a {
+		if px, err := strconv.Atoi(s); err == nil {
+			lengths[i] = Length{Value: float64(px), Unit: "px"}
+		} else if sizeSpec.MatchString(s) {
+			lengths[i], _ = parseLength(s)
+		} else {
+			return Length{}, Length{}, err
+		}
+	}
+	if len(a) == 1 {
+		lengths[1] = lengths[0]
+	}
+	return lengths[0], lengths[1], nil
 }
 
 func parseScale(spec string) (float64, float64, error) {
@@ -390,6 +720,28 @@ func parseScale(spec string) (float64, float64, error) {
 	return x, y, err
 }
 
+// parse aspect spec e.g. "16/9" or "1.5"
+func parseAspect(spec string) (float64, error) {
+	a := strings.Split(spec, "/")
+	if len(a) > 2 {
+		return 0, fmt.Errorf("invalid aspect %s, expected width/height", spec)
+	}
+	w, err := strconv.ParseFloat(strings.TrimSpace(a[0]), 64)
+	if err != nil {
+		return 0, err
+	}
+	h := 1.0
+	if len(a) == 2 {
+		if h, err = strconv.ParseFloat(strings.TrimSpace(a[1]), 64); err != nil {
+			return 0, err
+		}
+	}
+	if w <= 0 || h <= 0 {
+		return 0, fmt.Errorf("invalid aspect %s, must be positive", spec)
+	}
+	return w / h, nil
+}
+
 func loadImage(spec string) (*ebiten.Image, error) {
 	if spec == "" {
 		return nil, nil
@@ -560,18 +912,54 @@ func parseColor(spec string) (color.Color, error) {
 	return c, nil
 }
 
-func parseSize(spec string, f font.Face) (int, error) {
+// parse an absolute size e.g. "24px", "1.5em" or "2lh", with pixels multiplied by the UI scale
+func parseSize(spec string, f font.Face, scale float64) (int, error) {
+	l, err := parseLength(spec)
+	if err != nil {
+		return 0, err
+	}
+	if l.relative() {
+		return 0, fmt.Errorf("relative size %s not allowed here", spec)
+	}
+	return l.pixels(f, scale, 0, 0, 0), nil
+}
+
+// parse a length e.g. "24px", ".5em", "2lh", "50%", "100vw" or "25vh", an empty spec or "0" is no length
+func parseLength(spec string) (Length, error) {
+	if spec == "" || spec == "0" {
+		return Length{}, nil
+	}
 	matches := sizeSpec.FindStringSubmatch(spec)
-	if len(matches) == 3 {
-		size, _ := strconv.Atoi(matches[1])
-		switch matches[2] {
-		case "px":
-			return size, nil
-		case "em":
-			return size * text.BoundString(f, "M").Dx(), nil
-		case "lh":
-			return size * f.Metrics().Height.Round(), nil
-		}
-	}
-	return 0, nil
+	if len(matches) != 3 {
+		return Length{}, fmt.Errorf("invalid length %q, expected a number followed by em, px, lh, %%, vw or vh", spec)
+	}
+	v, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return Length{}, err
+	}
+	return Length{Value: v, Unit: matches[2]}, nil
+}
+
+func (l Length) relative() bool {
+	return l.Unit == "%" || l.Unit == "vw" || l.Unit == "vh"
+}
+
+// the length in pixels, with percentages of base and viewport units of the viewport width and height
+func (l Length) pixels(f font.Face, scale float64, base, vw, vh int) int {
+	var px float64
+	switch l.Unit {
+	case "px":
+		px = l.Value * scale
+	case "em":
+		px = l.Value * float64(text.BoundString(f, "M").Dx())
+	case "lh":
+		px = l.Value * float64(f.Metrics().Height.Round())
+	case "%":
+		px = l.Value * float64(base) / 100
+	case "vw":
+		px = l.Value * float64(vw) / 100
+	case "vh":
+		px = l.Value * float64(vh) / 100
+	}
+	return int(math.Round(px))
 }
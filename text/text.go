@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -199,6 +200,21 @@ func BoundString(face font.Face, text string) image.Rectangle {
 	)
 }
 
+// Baseline returns the distance from the top of a box of the given height to the baseline of the text drawn in it
+// by DrawString with vertical alignment va.
+func Baseline(face font.Face, height int, va Alignment) int {
+	mBounds := glyphBounds(face, 'M')
+	oy := fixed26_6ToFloat64(mBounds.Min.Y)
+	th := fixed26_6ToFloat64(mBounds.Max.Y) - oy
+	switch va {
+	case Center:
+		return int(math.Round(-oy + float64(height)/2 - th/2))
+	case End:
+		return int(math.Round(-oy + float64(height) - th))
+	}
+	return int(math.Round(-oy))
+}
+
 // DrawString draws a given text on a given destination image dst.
 //
 // face is the font for text rendering.
@@ -304,6 +320,25 @@ func DrawString(dst *ebiten.Image, text string, face font.Face, clr color.Color,
 	return nil
 }
 
+// Ellipsize shortens text with a trailing ellipsis so that it fits within width, or returns it unchanged if it already fits
+func Ellipsize(face font.Face, text string, width int) string {
+	if BoundString(face, text).Dx() <= width {
+		return text
+	}
+	runes := []rune(text)
+	// find the longest prefix that fits with the ellipsis
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if BoundString(face, string(runes[:mid])+"…").Dx() <= width {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return strings.TrimRight(string(runes[:lo]), " ") + "…"
+}
+
 func BoundParagraph(face font.Face, text string, maxWidth int) image.Rectangle {
 	m := face.Metrics()
 	lineHeight := m.Height
@@ -21,3 +21,14 @@ func TestBoundParagraph(t *testing.T) {
 		t.Errorf("bound calculation did not include trailing space")
 	}
 }
+
+func TestEllipsize(t *testing.T) {
+	face := fonts["NotoSans"].load(16)
+	if got := Ellipsize(face, "Hello World", 1000); got != "Hello World" {
+		t.Errorf("got %q, want text that fits unchanged", got)
+	}
+	width := BoundString(face, "Hello W…").Dx()
+	if got := Ellipsize(face, "Hello World", width); got != "Hello W…" {
+		t.Errorf("got %q, want %q", got, "Hello W…")
+	}
+}
@@ -0,0 +1,156 @@
+package bento
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A ScaleMode is how a UI with a virtual resolution is scaled onto a screen with a different size
+type ScaleMode int
+
+const (
+	// Letterbox scales the UI uniformly until it fits the screen, centered between bars on two of the sides
+	Letterbox = ScaleMode(iota)
+	// Fit scales the UI uniformly until it fits the screen, and extends the virtual resolution along one axis to the
+	// aspect ratio of the screen, so the UI fills the screen without bars
+	Fit
+	// Fill scales the UI uniformly until it covers the screen, centered and cropped on two of the sides
+	Fill
+)
+
+// how the root is drawn onto the screen, when it isn't drawn directly
+type screenTransform struct {
+	geom      *ebiten.GeoM
+	virtual   image.Point
+	mode      ScaleMode
+	offscreen *ebiten.Image
+	inverse   ebiten.GeoM
+	singular  bool // nothing on the screen maps back to the UI
+	pointer   func(x, y int) (int, int, bool)
+}
+
+// map pointer input back through the inverse of geom, the pointer is never over the UI when geom is singular
+func (t *screenTransform) invert(geom ebiten.GeoM) {
+	t.inverse = geom
+	t.singular = !geom.IsInvertible()
+	if !t.singular {
+		t.inverse.Invert()
+	}
+}
+
+// SetTransform draws the UI at the size of the screen into an offscreen image, which is drawn onto the screen
+// transformed by geom from UI space to screen space, and maps pointer input back through the inverse of geom
+func (n *Box) SetTransform(geom ebiten.GeoM) {
+	root := n.root()
+	root.screen.geom = &geom
+	root.screen.virtual = image.Point{}
+	root.screen.invert(geom)
+}
+
+// SetVirtualResolution lays the UI out at a fixed width and height, e.g. 1920x1080, whatever the size of the screen,
+// and scales it onto the screen with the mode. Sizes are not multiplied by the device scale factor unless the scale
+// is set explicitly.
+func (n *Box) SetVirtualResolution(width, height int, mode ScaleMode) {
+	root := n.root()
+	root.screen.geom = nil
+	root.screen.virtual = image.Pt(width, height)
+	root.screen.mode = mode
+}
+
+func (n *Box) transformed() bool {
+	return n.screen.geom != nil || n.screen.virtual != (image.Point{})
+}
+
+// the size of the UI and the transform from UI space to screen space for a screen of the given size
+func (n *Box) screenGeoM(screen image.Point) (image.Point, ebiten.GeoM) {
+	if n.screen.virtual == (image.Point{}) {
+		return screen, *n.screen.geom
+	}
+	size := n.screen.virtual
+	w, h := float64(size.X), float64(size.Y)
+	sw, sh := float64(screen.X), float64(screen.Y)
+	s := math.Min(sw/w, sh/h)
+	switch n.screen.mode {
+	case Fit:
+		size = image.Pt(int(math.Round(sw/s)), int(math.Round(sh/s)))
+	case Fill:
+		s = math.Max(sw/w, sh/h)
+	}
+	var geom ebiten.GeoM
+	geom.Scale(s, s)
+	geom.Translate((sw-float64(size.X)*s)/2, (sh-float64(size.Y)*s)/2)
+	return size, geom
+}
+
+// the size of the UI and its transform onto a screen of the given size, or false if the UI isn't visible on it
+func (n *Box) fitScreen(screen image.Point) (image.Point, ebiten.GeoM, bool) {
+	size, geom := n.screenGeoM(screen)
+	if size.X <= 0 || size.Y <= 0 {
+		n.screen.singular = true
+		return size, geom, false
+	}
+	n.screen.invert(geom)
+	return size, geom, !n.screen.singular
+}
+
+// draw the root into the offscreen image, and the offscreen image onto the screen
+func (n *Box) drawTransformed(screen *ebiten.Image) {
+	size, geom, ok := n.fitScreen(screen.Bounds().Size())
+	if !ok {
+		return
+	}
+	if n.screen.offscreen == nil || n.screen.offscreen.Bounds().Size() != size {
+		n.screen.offscreen = ebiten.NewImage(size.X, size.Y)
+	}
+	n.screen.offscreen.Clear()
+	n.draw(n.screen.offscreen)
+	op := &ebiten.DrawImageOptions{GeoM: geom, Filter: ebiten.FilterLinear}
+	screen.DrawImage(n.screen.offscreen, op)
+}
+
+// SetPointer routes pointer input through fn, which maps a position on the screen to UI space, or reports false when
+// the pointer is not over the UI, e.g. for a UI drawn into an image that is placed in the world with a camera
+// transform. The pointer function replaces the inverse of the transform.
+func (n *Box) SetPointer(fn func(x, y int) (int, int, bool)) {
+	n.root().screen.pointer = fn
+}
+
+// GeoMPointer is a pointer function for a UI drawn into an image of the given size, which is drawn onto the screen
+// transformed by geom. The pointer is over the UI when it is inside the transformed image, and never when geom is
+// singular.
+func GeoMPointer(geom ebiten.GeoM, width, height int) func(x, y int) (int, int, bool) {
+	if !geom.IsInvertible() {
+		return func(x, y int) (int, int, bool) {
+			return 0, 0, false
+		}
+	}
+	geom.Invert()
+	return func(x, y int) (int, int, bool) {
+		ux, uy := geom.Apply(float64(x), float64(y))
+		px, py := int(math.Floor(ux)), int(math.Floor(uy))
+		return px, py, px >= 0 && py >= 0 && px < width && py < height
+	}
+}
+
+// map a point on the screen to UI space, reporting false when the point is not over the UI
+func (n *Box) toUI(x, y int) (int, int, bool) {
+	root := n.root()
+	if root.screen.pointer != nil {
+		return root.screen.pointer(x, y)
+	}
+	if !root.transformed() {
+		return x, y, true
+	}
+	if root.screen.singular {
+		return 0, 0, false
+	}
+	ux, uy := root.screen.inverse.Apply(float64(x), float64(y))
+	return int(math.Floor(ux)), int(math.Floor(uy)), true
+}
+
+// the position of the pointer in UI space
+func (n *Box) cursor() (int, int, bool) {
+	return n.toUI(ebiten.CursorPosition())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -20,23 +21,35 @@ var allowedTags = []string{
 	"input",
 	"textarea",
 	"canvas",
+	"grid",
+	"list",
+	"stack",
 }
 
-func (n *Box) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	n.Tag = start.Name.Local
-	allowed := false
-	for _, tag := range allowedTags {
-		if n.Tag == tag {
-			allowed = true
+func checkTag(tag string) error {
+	for _, allowed := range allowedTags {
+		if tag == allowed {
+			return nil
 		}
 	}
-	if !allowed {
-		if r, _ := utf8.DecodeRuneInString(n.Tag); !unicode.IsUpper(r) {
-			return fmt.Errorf("unsupported tag %s, allowed tags: %v", n.Tag, allowedTags)
-		}
+	if r, _ := utf8.DecodeRuneInString(tag); !unicode.IsUpper(r) {
+		return fmt.Errorf("unsupported tag %s, allowed tags: %v", tag, allowedTags)
+	}
+	return nil
+}
+
+func (n *Box) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.Tag = start.Name.Local
+	if err := checkTag(n.Tag); err != nil {
+		return err
 	}
 	n.Attrs = make(map[string]string)
 	for _, attr := range start.Attr {
+		// prefixed attributes are qualified by a breakpoint, e.g. lg:padding
+		if attr.Name.Space != "" {
+			n.Attrs[attr.Name.Space+":"+attr.Name.Local] = attr.Value
+			continue
+		}
 		n.Attrs[attr.Name.Local] = attr.Value
 	}
 	for {
@@ -67,3 +80,59 @@ func (n *Box) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 	}
 }
+
+func (n *Box) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return n.marshalXML(e, false)
+}
+
+// MarshalLayout encodes the box tree as XML, including the computed layout of each box as attributes
+func (n *Box) MarshalLayout() ([]byte, error) {
+	return xml.MarshalIndent(layoutMarshaler{n}, "", "\t")
+}
+
+type layoutMarshaler struct {
+	*Box
+}
+
+func (m layoutMarshaler) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return m.marshalXML(e, true)
+}
+
+func (n *Box) marshalXML(e *xml.Encoder, withLayout bool) error {
+	if n.Tag == "" {
+		return fmt.Errorf("can't marshal box without a tag")
+	}
+	start := xml.StartElement{Name: xml.Name{Local: n.Tag}}
+	keys := make([]string, 0, len(n.Attrs))
+	for k := range n.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k}, Value: n.Attrs[k]})
+	}
+	if withLayout {
+		v := reflect.ValueOf(n.layout)
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			if _, exists := n.Attrs[name]; exists || v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: fmt.Sprint(v.Field(i).Interface())})
+		}
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.Content != "" {
+		if err := e.EncodeToken(xml.CharData(n.Content)); err != nil {
+			return err
+		}
+	}
+	for _, c := range n.Children {
+		if err := c.marshalXML(e, withLayout); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
@@ -2,6 +2,7 @@ package bento
 
 import (
 	"encoding/xml"
+	"fmt"
 	"log"
 	"testing"
 )
@@ -45,3 +46,51 @@ func TestUnmarshalXML(t *testing.T) {
 		log.Fatal(err)
 	}
 }
+
+func TestMarshalXML(t *testing.T) {
+	c := &BasicComponent{
+		Count: 1,
+		Array: []string{"a", "b"},
+	}
+	box, err := Build(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := xml.Marshal(box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<col><row><text>1</text></row><col><text>One</text></col><col><text>a</text><text>b</text></col><input value="1"></input></col>`
+	if got := string(data); got != want {
+		t.Fatalf("got\n%s\nwant\n%s\n", got, want)
+	}
+	got := &Box{}
+	if err := xml.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.diff(box); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalLayout(t *testing.T) {
+	box, err := Build(&BasicComponent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.relayout()
+	data, err := box.MarshalLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Box{}
+	if err := xml.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprint(box.OuterWidth); got.Attrs["OuterWidth"] != want {
+		t.Fatalf("got OuterWidth=%q, want %q", got.Attrs["OuterWidth"], want)
+	}
+	if want := fmt.Sprint(box.Children[0].Children[0].ContentHeight); got.Children[0].Children[0].Attrs["ContentHeight"] != want {
+		t.Fatalf("got ContentHeight=%q, want %q", got.Children[0].Children[0].Attrs["ContentHeight"], want)
+	}
+}